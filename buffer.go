@@ -6,7 +6,6 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
-	"strings"
 	"sync"
 	"time"
 
@@ -15,7 +14,7 @@ import (
 
 // BufferManager handles pre-downloading songs to maintain a buffer
 type BufferManager struct {
-	downloadQueue []Song
+	downloadQueue []Track
 	downloading   map[string]bool   // Track which songs are currently downloading
 	failedVideos  map[string]int    // Track failed download attempts per video ID
 	lastFailTime  map[string]time.Time // Track when each video last failed
@@ -30,7 +29,7 @@ type BufferManager struct {
 // NewBufferManager creates a new buffer manager
 func NewBufferManager(maxBuffer int) *BufferManager {
 	return &BufferManager{
-		downloadQueue: make([]Song, 0),
+		downloadQueue: make([]Track, 0),
 		downloading:   make(map[string]bool),
 		failedVideos:  make(map[string]int),
 		lastFailTime:  make(map[string]time.Time),
@@ -71,7 +70,7 @@ func (bm *BufferManager) StopBuffering() {
 		}
 	}
 
-	bm.downloadQueue = make([]Song, 0)
+	bm.downloadQueue = make([]Track, 0)
 	bm.downloading = make(map[string]bool)
 	bm.failedVideos = make(map[string]int)
 	bm.lastFailTime = make(map[string]time.Time)
@@ -80,7 +79,7 @@ func (bm *BufferManager) StopBuffering() {
 }
 
 // UpdateQueue updates the buffer manager with the current queue state
-func (bm *BufferManager) UpdateQueue(currentQueue []Song, currentPlayingIndex int) {
+func (bm *BufferManager) UpdateQueue(currentQueue []Track, currentPlayingIndex int) {
 	bm.mutex.Lock()
 	defer bm.mutex.Unlock()
 
@@ -89,7 +88,7 @@ func (bm *BufferManager) UpdateQueue(currentQueue []Song, currentPlayingIndex in
 	}
 
 	// Calculate which songs need to be in the buffer
-	var songsToBuffer []Song
+	var songsToBuffer []Track
 	startIndex := currentPlayingIndex + 1 // Start with next song after currently playing
 
 	for i := 0; i < bm.maxBuffer && startIndex+i < len(currentQueue); i++ {
@@ -141,7 +140,7 @@ func (bm *BufferManager) recordFailure(videoID string) {
 }
 
 // PreDownloadInitialSongs downloads the first few songs before starting playback
-func (bm *BufferManager) PreDownloadInitialSongs(songs []Song, session *discordgo.Session, channelID string) error {
+func (bm *BufferManager) PreDownloadInitialSongs(songs []Track, session *discordgo.Session, channelID string) error {
 	if len(songs) == 0 {
 		return nil
 	}
@@ -171,7 +170,7 @@ func (bm *BufferManager) PreDownloadInitialSongs(songs []Song, session *discordg
 
 	for i, song := range songsToDownload {
 		wg.Add(1)
-		go func(song Song, index int) {
+		go func(song Track, index int) {
 			defer wg.Done()
 			semaphore <- struct{}{}        // Acquire semaphore
 			defer func() { <-semaphore }() // Release semaphore
@@ -223,20 +222,20 @@ func (bm *BufferManager) maintainBuffer() {
 			}
 
 			// Check which songs in the buffer need downloading
-			var songsToDownload []Song
+			var songsToDownload []Track
 			for _, song := range bm.downloadQueue {
 				// Skip if already cached
-				if metadataManager.HasSong(song.VidID) {
+				if metadataManager.HasSong(song.VideoID) {
 					continue
 				}
 
 				// Skip if currently downloading
-				if bm.downloading[song.VidID] {
+				if bm.downloading[song.VideoID] {
 					continue
 				}
 
 				// Skip if failed too many times or in backoff period
-				if bm.shouldSkipDownload(song.VidID) {
+				if bm.shouldSkipDownload(song.VideoID) {
 					continue
 				}
 
@@ -247,18 +246,18 @@ func (bm *BufferManager) maintainBuffer() {
 			// Download songs that need downloading (limit concurrent downloads)
 			if len(songsToDownload) > 0 {
 				for _, song := range songsToDownload[:min(4, len(songsToDownload))] {
-					go func(s Song) {
+					go func(s Track) {
 						bm.mutex.Lock()
-						bm.downloading[s.VidID] = true
+						bm.downloading[s.VideoID] = true
 						bm.mutex.Unlock()
 
 						success := bm.downloadSong(s, 0, 0) // 0 index means background download
 
 						bm.mutex.Lock()
-						delete(bm.downloading, s.VidID)
+						delete(bm.downloading, s.VideoID)
 						if !success {
 							// Record the failure for this video
-							bm.recordFailure(s.VidID)
+							bm.recordFailure(s.VideoID)
 						}
 						bm.mutex.Unlock()
 
@@ -273,9 +272,9 @@ func (bm *BufferManager) maintainBuffer() {
 }
 
 // downloadSong downloads a single song and updates metadata
-func (bm *BufferManager) downloadSong(song Song, progressIndex, totalCount int) bool {
+func (bm *BufferManager) downloadSong(song Track, progressIndex, totalCount int) bool {
 	// Check if already cached
-	if metadataManager.HasSong(song.VidID) {
+	if metadataManager.HasSong(song.VideoID) {
 		if progressIndex > 0 {
 			log.Printf("INFO: Song %d/%d already cached: %s", progressIndex, totalCount, song.Title)
 		}
@@ -302,24 +301,24 @@ func (bm *BufferManager) downloadSong(song Song, progressIndex, totalCount int)
 }
 
 // downloadSongToCache downloads a song and saves it to cache with metadata
-func downloadSongToCache(song Song) bool {
-	log.Printf("INFO: Downloading to cache: %s (ID: %s)", song.Title, song.VidID)
+func downloadSongToCache(song Track) bool {
+	log.Printf("INFO: Downloading to cache: %s (ID: %s)", song.Title, song.VideoID)
 
 	// For manual/local files, they're already "downloaded"
-	if song.VideoURL == "" || song.VideoURL == song.Title {
+	if song.Source == SourceLocal {
 		log.Printf("INFO: Local file, marking as cached: %s", song.Title)
 		return true
 	}
 
 	// Check if it's already a file path
-	if strings.HasPrefix(song.VideoURL, "downloads/") {
-		log.Printf("INFO: Already downloaded file: %s", song.VideoURL)
+	if song.FilePath != "" {
+		log.Printf("INFO: Already downloaded file: %s", song.FilePath)
 		return true
 	}
 
 	// For YouTube URLs, download using yt-dlp
 	// Always use the YouTube URL format for yt-dlp, not the stream URL
-	videoID := song.VidID
+	videoID := song.VideoID
 	originalURL := "https://www.youtube.com/watch?v=" + videoID
 
 	// Create downloads directory if it doesn't exist
@@ -27,6 +27,11 @@ func queueSong(m *discordgo.MessageCreate) {
 		return
 	}
 
+	// Songs can only be added from the channel the bot is playing in
+	if !requireSameVoiceChannel(m) {
+		return
+	}
+
 	// Check user rate limiting for heavy operations
 	if !checkUserRateLimit(m.Author.ID) {
 		s.ChannelMessageSend(m.ChannelID, "**[Muse]** ⏳ Please wait a moment before adding more content. (Rate limited)")
@@ -60,25 +65,23 @@ func queueSong(m *discordgo.MessageCreate) {
 	// Clear stop flag when starting new queue operation
 	setStopRequested(false)
 
-	// Check if a youtube link is present
-	if strings.Contains(m.Content, "https://www.youtube") {
-		// Check if the link is a playlist or a simple video
-		// Route ALL playlist URLs to the enhanced yt-dlp-based processor
-		if (strings.Contains(m.Content, "list") && strings.Contains(m.Content, "-pl")) || 
-		   strings.Contains(m.Content, "/playlist?") || 
-		   strings.Contains(m.Content, "list=PL") {
-			playbackAlreadyStarted = prepWatchCommand(commData, m)
-		} else if strings.Contains(m.Content, "watch") && !strings.Contains(m.Content, "-pl") {
-			playbackAlreadyStarted = prepWatchCommand(commData, m)
-		}
-		resetSearch() // In case a search was called prior to this
+	// Check if a youtube link is present (watch, youtu.be, shorts, music, embed, live or playlist)
+	if len(commData) >= 2 && strings.ToLower(commData[1]) == "channel" {
+		// Channel uploads go through the same flat-playlist processor, which starts playback itself
+		prepChannelCommand(commData, m)
+		playbackAlreadyStarted = true
+		resetSearch(m.GuildID, m.Author.ID)
+	} else if findYouTubeLink(commData) != "" {
+		// Playlists are routed to the yt-dlp-based processor, single videos are queued directly
+		playbackAlreadyStarted = prepWatchCommand(commData, m)
+		resetSearch(m.GuildID, m.Author.ID) // In case a search was called prior to this
 	} else {
 		// Search or queue input was sent
 		prepSearchQueueSelector(commData, m)
 	}
 
 	// If there's nothing playing and the queue grew AND playback wasn't already started
-	if !playbackAlreadyStarted && v.nowPlaying == (Song{}) && len(queue) >= 1 {
+	if !playbackAlreadyStarted && v.nowPlaying == (Track{}) && len(queue) >= 1 {
 		// Set current user for voice operations (server-agnostic)
 		v.currentUserID = m.Author.ID
 
@@ -91,7 +94,7 @@ func queueSong(m *discordgo.MessageCreate) {
 			return
 		}
 		prepFirstSongEntered(m, false)
-	} else if !playbackAlreadyStarted && !searchRequested && !isStopRequested() && !isPlaybackEnding() {
+	} else if !playbackAlreadyStarted && !hasActiveSearch(m.GuildID, m.Author.ID) && !isStopRequested() && !isPlaybackEnding() {
 		prepDisplayQueue(commData, queueLenBefore, m)
 	}
 }
@@ -128,11 +131,11 @@ func queueStuff(m *discordgo.MessageCreate) {
 	}
 
 	for _, file := range files {
-		song = fillSongInfo(m.ChannelID, m.Author.ID, m.ID, "manual entry", file.Name(), "none")
+		song = newLocalTrack(m, file.Name())
 		queue = append(queue, song)
 	}
 
-	if v.nowPlaying == (Song{}) && len(queue) >= 1 {
+	if v.nowPlaying == (Track{}) && len(queue) >= 1 {
 		joinVoiceChannel()
 		prepFirstSongEntered(m, true)
 	}
@@ -149,6 +152,10 @@ func stop(m *discordgo.MessageCreate) {
 	setCommandActive(m.Author.ID, "stop")
 	defer clearCommandActive(m.Author.ID, "stop")
 
+	if !requireSameVoiceChannel(m) {
+		return
+	}
+
 	setPlaybackEnding(true) // Set flag to prevent inappropriate error messages
 
 	// Emergency cleanup for any stuck processes
@@ -157,21 +164,21 @@ func stop(m *discordgo.MessageCreate) {
 	s.ChannelMessageSend(m.ChannelID, "**[Muse]** Stopping ["+v.nowPlaying.Title+"] & Clearing Queue :octagonal_sign:")
 	v.stop = true
 	v.paused = false // Reset pause state when stopping
+	v.looping = false
 
 	// Clear queue and reset all processing flags
 	queueMutex.Lock()
-	queue = []Song{}
+	queue = []Track{}
+	unshuffledQueue = nil
 	queueMutex.Unlock()
 
 	setStopRequested(true)       // Set flag to prevent additional queue processing
-	resetSearch()
+	resetSearch(m.GuildID, m.Author.ID)
 
 	// Stop buffer manager
 	bufferManager.StopBuffering()
 
-	if v.encoder != nil {
-		v.encoder.Cleanup()
-	}
+	stopPlayback()
 
 	if v.voice != nil {
 		v.voice.Disconnect()
@@ -195,7 +202,7 @@ func emergencyCleanup() {
 	setStopRequested(false)
 	setPlaybackEnding(false)
 	setPlaybackState(false) // Reset playback state
-	v.paused = false        // Reset pause state
+	v.setPaused(false)      // Reset pause state
 
 	// Clear any rate limiting
 	userRateMutex.Lock()
@@ -232,9 +239,13 @@ func skip(m *discordgo.MessageCreate) {
 	setCommandActive(m.Author.ID, "skip")
 	defer clearCommandActive(m.Author.ID, "skip")
 
+	if !requireSameVoiceChannel(m) {
+		return
+	}
+
 	// Check if skipping current song or skipping to another song
 	if m.Content == "skip" {
-		if v.nowPlaying == (Song{}) {
+		if v.nowPlaying == (Track{}) {
 			err := NewQueueError("No song currently playing", "Queue is empty - There's nothing to skip!", nil).
 				WithContext("user_id", m.Author.ID)
 			errorHandler.Handle(err, m.ChannelID)
@@ -245,16 +256,13 @@ func skip(m *discordgo.MessageCreate) {
 
 		// Show what's playing next
 		queueMutex.Lock()
-		if len(queue) > 0 {
-			nextSong := queue[0]
-			s.ChannelMessageSend(m.ChannelID, "**[Muse]** Next! Now playing ["+nextSong.Title+"] :notes:")
-		} else {
+		if len(queue) == 0 {
 			s.ChannelMessageSend(m.ChannelID, "**[Muse]** No more songs in queue after this skip.")
 		}
 		queueMutex.Unlock()
 
 		prepSkip()
-		resetSearch()
+		resetSearch(m.GuildID, m.Author.ID)
 		log.Println("Skipped " + v.nowPlaying.Title)
 	} else if strings.Contains(m.Content, "skip to ") || (strings.HasPrefix(m.Content, "skip ") && m.Content != "skip") {
 		msgData := strings.Split(m.Content, " ")
@@ -263,15 +271,26 @@ func skip(m *discordgo.MessageCreate) {
 
 		// Handle both \"skip to X\" and \"skip X\" formats
 		if strings.Contains(m.Content, "skip to ") {
-			// Can only accept 3 params: skip to #
-			if len(msgData) != 3 {
-				validationErr := NewValidationError("Invalid format. Use 'skip to [number]' or 'skip [number]'", nil).
+			// skip to # or skip to <part of a title>
+			if len(msgData) < 3 {
+				validationErr := NewValidationError("Invalid format. Use 'skip to [number or title]' or 'skip [number]'", nil).
 					WithContext("command", m.Content).
 					WithContext("user_id", m.Author.ID)
 				errorHandler.Handle(validationErr, m.ChannelID)
 				return
 			}
 			targetPosition, err = strconv.Atoi(msgData[2])
+			if err != nil || len(msgData) > 3 {
+				queueMutex.Lock()
+				var problem string
+				targetPosition, problem = resolveQueueTitleLocked(strings.Join(msgData[2:], " "))
+				queueMutex.Unlock()
+				if targetPosition == 0 {
+					s.ChannelMessageSend(m.ChannelID, problem)
+					return
+				}
+				err = nil
+			}
 		} else {
 			// Handle \"skip X\" format
 			if len(msgData) != 2 {
@@ -310,7 +329,7 @@ func skip(m *discordgo.MessageCreate) {
 
 		// Skip to the target position
 		queueMutex.Lock()
-		var tmp []Song
+		var tmp []Track
 		for i, value := range queue {
 			if i >= targetPosition-1 {
 				tmp = append(tmp, value)
@@ -324,7 +343,7 @@ func skip(m *discordgo.MessageCreate) {
 		log.Printf("Jumping to [%s] at position %d", targetSong.Title, targetPosition)
 
 		prepSkip()
-		resetSearch()
+		resetSearch(m.GuildID, m.Author.ID)
 	}
 }
 
@@ -339,124 +358,13 @@ func displayQueue(m *discordgo.MessageCreate) {
 	setCommandActive(m.Author.ID, "queue")
 	defer clearCommandActive(m.Author.ID, "queue")
 
-	s.ChannelMessageSend(m.ChannelID, "**[Muse]** Fetching Queue...")
-
-	// Thread-safe queue access
-	queueMutex.Lock()
-	queueCopy := make([]Song, len(queue))
-	copy(queueCopy, queue)
-	queueMutex.Unlock()
-
-	// Always show complete queue with proper pagination
-	if v.nowPlaying != (Song{}) {
-		// Build header with now playing
-		queueList := ":musical_note:   QUEUE LIST   :musical_note:\n"
-		queueList += "Now Playing: " + v.nowPlaying.Title + "  ->  Queued by <@" + v.nowPlaying.User + "> \n \n"
-
-		// Add queue count info
-		if len(queueCopy) > 0 {
-			queueList += fmt.Sprintf("**Upcoming Songs (%d in queue):**\n", len(queueCopy))
-		}
-
-		log.Printf("Queue display: Starting with %d songs, header length: %d chars", len(queueCopy), len(queueList))
-
-		// Process queue with very aggressive pagination to prevent ANY truncation
-		// TODO: consts should be in a config file
-		const maxMessageLength = 1000 // Very conservative limit to prevent Discord truncation
-		const maxSongsPerMessage = 10 // Force pagination every 10 songs regardless of length
-		currentMessage := queueList
-		songsSentInThisMessage := 0
-
-		for index, element := range queueCopy {
-			songLine := fmt.Sprintf("%d. %s  ->  Queued by <@%s>\n", index+1, element.Title, element.User)
-
-			// Check if we need pagination (either too long OR too many songs)
-			if len(currentMessage)+len(songLine) > maxMessageLength || songsSentInThisMessage >= maxSongsPerMessage {
-				// Send current message
-				s.ChannelMessageSend(m.ChannelID, currentMessage)
-
-				// Start fresh message for continuation (no header, just songs)
-				currentMessage = ""
-				songsSentInThisMessage = 0
-			}
-
-			// Add the song to current message
-			currentMessage += songLine
-			songsSentInThisMessage++
-		}
-
-		// Send the final message (or only message if queue is small)
-		if len(currentMessage) > 0 {
-			s.ChannelMessageSend(m.ChannelID, currentMessage)
-		}
-
-	} else if len(queueCopy) == 0 {
-		// No song playing and no queue
-		queueList := ":musical_note:   QUEUE LIST   :musical_note:\n"
-		queueList += "**Nothing is currently playing and the queue is empty.** :sleeping:\n"
-		queueList += "Use `play [song/URL]` to add music to the queue!"
-		s.ChannelMessageSend(m.ChannelID, queueList)
-	} else {
-		// There are songs in queue but nothing is currently playing
-		queueList := ":musical_note:   QUEUE LIST   :musical_note:\n"
-		queueList += "**Nothing is currently playing**\n\n"
-		queueList += fmt.Sprintf("**Queue (%d songs):**\n", len(queueCopy))
-
-		// Process queue with pagination
-		const maxMessageLength = 1000
-		const maxSongsPerMessage = 10
-		currentMessage := queueList
-		songsSentInThisMessage := 0
-
-		for index, element := range queueCopy {
-			songLine := fmt.Sprintf("%d. %s  ->  Queued by <@%s>\n", index+1, element.Title, element.User)
-
-			// Check if we need pagination
-			if len(currentMessage)+len(songLine) > maxMessageLength || songsSentInThisMessage >= maxSongsPerMessage {
-				// Send current message
-				s.ChannelMessageSend(m.ChannelID, currentMessage)
-
-				// Start fresh message for continuation (no header, just songs)
-				currentMessage = ""
-				songsSentInThisMessage = 0
-			}
-
-			// Add the song to current message
-			currentMessage += songLine
-			songsSentInThisMessage++
-		}
-
-		// Send the final message
-		if len(currentMessage) > 0 {
-			s.ChannelMessageSend(m.ChannelID, currentMessage)
-		}
-	}
-}
-
-// Removes a song from the queue at a specific position
-func remove(m *discordgo.MessageCreate) {
-	// Split the message to get which song to remove from the queue
-	commData := strings.Split(m.Content, " ")
-	var msgToUser string
-	if len(commData) == 2 {
-		if queuePos, err := strconv.Atoi(commData[1]); err == nil {
-			if queue != nil {
-				if 1 <= queuePos && queuePos <= len(queue) {
-					queuePos--
-					var songTitle = queue[queuePos].Title
-					var tmpQueue []Song
-					tmpQueue = queue[:queuePos]
-					tmpQueue = append(tmpQueue, queue[queuePos+1:]...)
-					queue = tmpQueue
-					msgToUser = fmt.Sprintf("**[Muse]** Removed %s.", songTitle)
-				} else {
-					msgToUser = "**[Muse]** The selection was out of range."
-				}
-			} else {
-				msgToUser = "**[Muse]** There is no queue to remove songs from."
-			}
-		}
-		s.ChannelMessageSend(m.ChannelID, msgToUser)
+	embed, components := renderQueuePage(0)
+	_, err := s.ChannelMessageSendComplex(m.ChannelID, &discordgo.MessageSend{
+		Embeds:     []*discordgo.MessageEmbed{embed},
+		Components: components,
+	})
+	if err != nil {
+		log.Printf("ERROR: Failed to send queue: %v", err)
 	}
 }
 
@@ -465,41 +373,64 @@ func showHelp(m *discordgo.MessageCreate) {
 	helpMessage := ":robot: **[Muse] HELP MENU** :robot:\n\n"
 	helpMessage += ":musical_note: **MUSIC COMMANDS** :musical_note:\n"
 	helpMessage += "`play [YouTube URL]` - Play a YouTube video or playlist\n"
-	helpMessage += "`play [search term]` - Search for and play a song\n"
+	helpMessage += "`play [search term]` - Search for a song, then pick a result from the menu or with `play [number]`\n"
+	helpMessage += "`play! [search term]` or `play --first [search term]` - Queue the top result right away\n"
+	helpMessage += "`play [--long|--short] [--channel name] [--exclude live] [search term]` - Filter search results (cached songs are listed first)\n"
+	helpMessage += "`play [playlist URL] [from-to] [--reverse|--shuffle] [--max-duration 10m]` - Queue part of a playlist\n"
+	helpMessage += "`playnext [URL/search term/queue position]` - Queue a song right after the current one\n"
+	helpMessage += "`playnow [URL/search term/queue position]` - Play a song right away, the current one resumes afterwards\n"
+	helpMessage += fmt.Sprintf("`play channel [channel URL or @handle] [count]` - Queue a channel's latest uploads (default %d)\n", defaultChannelUploads)
 	helpMessage += "`play stuff` - Queue all local MP3 files from mpegs folder\n"
 	helpMessage += "`stop` - Stop current song and clear the queue\n"
 	helpMessage += "`skip` - Skip the current song\n"
 	helpMessage += "`skip [number]` - Skip to a specific position in queue\n"
-	helpMessage += "`skip to [number]` - Skip to a specific position in queue\n"
+	helpMessage += "`skip to [number or title]` - Skip to a specific position in queue\n"
 	helpMessage += "`pause` - Pause the currently playing song\n"
 	helpMessage += "`resume` - Resume the paused song\n"
 	helpMessage += "`queue` - Display the current queue\n"
-	helpMessage += "`remove [number]` - Remove a song from the queue at position\n"
-	helpMessage += "`move [from] [to]` - Move a song from one position to another\n"
-	helpMessage += "`shuffle` - Shuffle the current queue\n"
+	helpMessage += "`join` (or `summon`, `move here`) - Bring the bot to your voice channel, even mid-song\n"
+	helpMessage += "`leave` - Stop playing and leave the voice channel (`undo` brings the queue back)\n"
+	helpMessage += "`nowplaying` (or `np`) - Show the current song with a live progress bar and playback buttons\n"
+	helpMessage += "`loop` - Repeat the current song until turned off\n"
+	helpMessage += "`remove [number|3-10|2,5,9|@user|dupes|unavailable|title]` - Remove songs from the queue\n"
+	helpMessage += "`clear` - Empty the queue but keep the current song playing\n"
+	helpMessage += "`undo` - Revert the last remove, move, shuffle or clear (within 5 minutes)\n"
+	helpMessage += "`move [from] [to]` - Move a song from one position to another (`from` can be part of the title)\n"
+	helpMessage += "`find [text]` - Show where matching songs are in the queue\n"
+	helpMessage += "`shuffle [smart]` - Shuffle the current queue (smart spreads out requesters and artists), `unshuffle` to undo\n"
 	helpMessage += "`history` - Show recently played songs in this server\n"
+	helpMessage += "`back` (or `previous`) - Play the previous song again, the current one resumes afterwards. Repeat it to go further back\n"
+	helpMessage += "`replay [number]` - Queue a song from `history` again\n"
 	helpMessage += "`cache` - Show cache statistics and information\n"
 	helpMessage += "`cache-clear` - Clear old cached songs (older than 7 days)\n"
-	helpMessage += "`buffer-status` - Show buffer manager status and download queue\n\n"
+	helpMessage += "`buffer-status` - Show buffer manager status and download queue\n"
+	helpMessage += "`status` - Show YouTube API quota usage and playback status\n"
+	helpMessage += "`settings` - Show server settings, `settings <name> <value>` to change one (fair queue, per-person song and time limits, max song length, duplicates)\n\n"
 	helpMessage += ":gear: **SYSTEM COMMANDS** :gear:\n"
 	helpMessage += "`emergency-reset` or `reset` - Emergency reset if bot gets stuck\n\n"
 	helpMessage += ":gear: **SETUP REQUIREMENTS** :gear:\n"
 	helpMessage += "• **BOT_TOKEN** - Your Discord bot token\n"
-	helpMessage += "• **YT_TOKEN** - Your YouTube Data API key\n"
+	helpMessage += "• **YT_TOKEN** - Your YouTube Data API key (optional, searches fall back to yt-dlp)\n"
 	helpMessage += "• **Join a voice channel** - Bot will auto-join your channel\n\n"
 	helpMessage += ":shield: **RATE LIMITING & PROTECTION** :shield:\n"
 	helpMessage += "• **Playlist Cooldown**: 5 seconds between playlists\n"
 	helpMessage += "• **User Rate Limiting**: 3 seconds between commands per user\n"
 	helpMessage += "• **Max Queue Size**: 500 songs total\n"
-	helpMessage += "• **Max Playlist Size**: 100 songs per playlist\n\n"
+	helpMessage += fmt.Sprintf("• **Max Playlist Size**: %d songs per playlist (use a range like `1-%d` for bigger ones)\n\n", maxPlaylistSize, maxPlaylistSize)
 	helpMessage += ":gear: **SUPPORTED FORMATS** :gear:\n"
-	helpMessage += "• YouTube videos: `https://www.youtube.com/watch?v=...`\n"
+	helpMessage += "• YouTube videos: `https://www.youtube.com/watch?v=...`, `youtu.be/...`, `/shorts/...`, `music.youtube.com`\n"
+	helpMessage += "• Timestamped links (`&t=1m30s`) start playing from that point\n"
 	helpMessage += "• YouTube playlists: `https://www.youtube.com/playlist?list=...`\n"
+	helpMessage += "• YouTube Mixes (`list=RD...`) and albums (`list=OLAK5uy_...`)\n"
+	helpMessage += "• YouTube channels: `https://www.youtube.com/@name`, `/channel/UC...` or just `@name`\n"
 	helpMessage += "• Search terms: `play [artist] - [song title]`\n"
 	helpMessage += "• :white_check_mark: **Age-restricted content** is supported with enhanced processing\n\n"
 	helpMessage += ":information_source: **EXAMPLES** :information_source:\n"
 	helpMessage += "`play https://www.youtube.com/watch?v=dQw4w9WgXcQ`\n"
 	helpMessage += "`play never gonna give you up`\n"
+	helpMessage += "`play! --channel \"lofi girl\" --long study beats`\n"
+	helpMessage += "`play https://www.youtube.com/playlist?list=... 50-120 --shuffle`\n"
+	helpMessage += "`play channel @name 5` - Queue the 5 latest uploads\n"
 	helpMessage += "`skip 3` - Skip to song #3 in queue\n"
 	helpMessage += "`remove 2` - Remove song #2 from queue\n"
 	helpMessage += "`pause` - Pause current song\n"
@@ -595,9 +526,9 @@ func bufferStatusCommand(s *discordgo.Session, m *discordgo.MessageCreate, args
 				break
 			} // Show next 3
 			status := ":white_circle:"
-			if bufferManager.downloading[song.VidID] {
+			if bufferManager.downloading[song.VideoID] {
 				status = ":orange_circle: Downloading..."
-			} else if metadataManager.HasSong(song.VidID) {
+			} else if metadataManager.HasSong(song.VideoID) {
 				status = ":green_circle: Cached"
 			}
 			response += fmt.Sprintf("%d. [%s] %s\n", i+1, song.Title, status)
@@ -607,6 +538,46 @@ func bufferStatusCommand(s *discordgo.Session, m *discordgo.MessageCreate, args
 	s.ChannelMessageSend(m.ChannelID, response)
 }
 
+func statusCommand(s *discordgo.Session, m *discordgo.MessageCreate) {
+	response := "**[Muse]** :satellite: **Status** :satellite:\n\n"
+
+	// YouTube Data API quota
+	if youtubeAPI == nil {
+		response += ":key: **YouTube API:** no API key configured, searching with yt-dlp\n"
+	} else {
+		usage := youtubeAPI.Usage()
+		percent := 0.0
+		if usage.Limit > 0 {
+			percent = float64(usage.Used) / float64(usage.Limit) * 100
+		}
+		response += fmt.Sprintf(":key: **YouTube API Quota:** %d / %d units (%.1f%%)\n", usage.Used, usage.Limit, percent)
+		if usage.Exhausted {
+			response += ":no_entry: **Quota exhausted** - searching with yt-dlp until the reset\n"
+		}
+		response += fmt.Sprintf(":clock3: **Quota Resets In:** %s\n", formatDuration(time.Until(usage.ResetsAt)))
+		for _, method := range usage.MethodNames() {
+			methodUsage := usage.Methods[method]
+			response += fmt.Sprintf("• `%s`: %d calls, %d units, %d cache hits\n", method, methodUsage.Calls, methodUsage.Units, methodUsage.CacheHits)
+		}
+	}
+	response += fmt.Sprintf(":mag: **yt-dlp Search Fallback:** %t\n\n", searchFallbackEnabled)
+
+	// Playback
+	queueMutex.Lock()
+	queueLength := len(queue)
+	queueMutex.Unlock()
+
+	if v.nowPlaying != (Track{}) {
+		response += fmt.Sprintf(":notes: **Now Playing:** [%s]\n", v.nowPlaying.Title)
+	} else {
+		response += ":notes: **Now Playing:** nothing\n"
+	}
+	response += fmt.Sprintf(":scroll: **Queue:** %d / %d songs\n", queueLength, maxQueueSize)
+	response += fmt.Sprintf(":loud_sound: **Voice Connected:** %t\n", v.voice != nil)
+
+	s.ChannelMessageSend(m.ChannelID, response)
+}
+
 // Helper function to format bytes into human-readable sizes
 func formatBytes(bytes int64) string {
 	const unit = 1024
@@ -623,21 +594,35 @@ func formatBytes(bytes int64) string {
 
 func moveQueueCommand(s *discordgo.Session, m *discordgo.MessageCreate, args []string) {
 	if len(args) < 2 {
-		s.ChannelMessageSend(m.ChannelID, "**[Muse]** Usage: `move [from] [to]` - Move song from position to position")
+		s.ChannelMessageSend(m.ChannelID, "**[Muse]** Usage: `move [from] [to]` - Move song from position to position, `from` can also be part of the title")
 		return
 	}
 
-	fromPos, err1 := strconv.Atoi(args[0])
-	toPos, err2 := strconv.Atoi(args[1])
-
-	if err1 != nil || err2 != nil {
+	// The target is always the last argument, anything before it is a position or a title fragment
+	from := strings.Join(args[:len(args)-1], " ")
+	toPos, err := strconv.Atoi(args[len(args)-1])
+	if err != nil {
 		s.ChannelMessageSend(m.ChannelID, "**[Muse]** Please provide valid position numbers")
 		return
 	}
 
+	if settingsFor(m.GuildID).FairQueue {
+		s.ChannelMessageSend(m.ChannelID, "**[Muse]** The fair queue decides the order on this server, songs can't be moved. Turn it off with `settings fairqueue off`.")
+		return
+	}
+
 	queueMutex.Lock()
 	defer queueMutex.Unlock()
 
+	fromPos, err := strconv.Atoi(from)
+	if err != nil {
+		var problem string
+		if fromPos, problem = resolveQueueTitleLocked(from); fromPos == 0 {
+			s.ChannelMessageSend(m.ChannelID, problem)
+			return
+		}
+	}
+
 	if fromPos < 1 || fromPos > len(queue) || toPos < 1 || toPos > len(queue) {
 		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("**[Muse]** Position must be between 1 and %d", len(queue)))
 		return
@@ -649,37 +634,18 @@ func moveQueueCommand(s *discordgo.Session, m *discordgo.MessageCreate, args []s
 
 	// Move the song
 	song := queue[fromPos]
+	recordQueueEditLocked("moved "+song.Title, nil)
 	// Remove from original position
 	queue = append(queue[:fromPos], queue[fromPos+1:]...)
 	// Insert at new position
 	if toPos > len(queue) {
 		toPos = len(queue)
 	}
-	queue = append(queue[:toPos], append([]Song{song}, queue[toPos:]...)...)
+	queue = append(queue[:toPos], append([]Track{song}, queue[toPos:]...)...)
 
 	s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("**[Muse]** :arrow_right: Moved [%s] to position %d", song.Title, toPos+1))
 }
 
-func shuffleQueueCommand(s *discordgo.Session, m *discordgo.MessageCreate, args []string) {
-	queueMutex.Lock()
-	defer queueMutex.Unlock()
-
-	if len(queue) <= 1 {
-		s.ChannelMessageSend(m.ChannelID, "**[Muse]** :twisted_rightwards_arrows: Queue needs at least 2 songs to shuffle")
-		return
-	}
-
-	// Simple shuffle algorithm
-	for i := len(queue) - 1; i > 0; i-- {
-		j := i % (i + 1) // Simple pseudo-random
-		if j != i {
-			queue[i], queue[j] = queue[j], queue[i]
-		}
-	}
-
-	s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("**[Muse]** :twisted_rightwards_arrows: Shuffled %d songs in the queue!", len(queue)))
-}
-
 func emergencyResetCommand(s *discordgo.Session, m *discordgo.MessageCreate) {
 	s.ChannelMessageSend(m.ChannelID, "**[Muse]** 🚨 **EMERGENCY RESET** - Clearing all processes and resetting bot state...")
 
@@ -697,12 +663,13 @@ func emergencyResetCommand(s *discordgo.Session, m *discordgo.MessageCreate) {
 
 	// Clear everything
 	queueMutex.Lock()
-	queue = []Song{}
+	queue = []Track{}
+	unshuffledQueue = nil
 	queueMutex.Unlock()
 
 	// Record interrupted song in history before clearing
 	if historyManager != nil && v.nowPlaying.Title != "" && !v.playStartTime.IsZero() {
-		playDuration := time.Since(v.playStartTime)
+		playDuration := v.nowPlaying.PlayedSoFar + v.playedFor()
 		guildName := ""
 		if guild, err := s.State.Guild(m.GuildID); err == nil {
 			guildName = guild.Name
@@ -712,16 +679,13 @@ func emergencyResetCommand(s *discordgo.Session, m *discordgo.MessageCreate) {
 		}
 	}
 
-	v.nowPlaying = Song{}
+	v.nowPlaying = Track{}
 
 	// Stop buffer manager
 	bufferManager.StopBuffering()
 
-	// Clean up encoder
-	if v.encoder != nil {
-		v.encoder.Cleanup()
-		v.encoder = nil
-	}
+	// Stop the audio
+	stopPlayback()
 
 	// Force drain playlist semaphore
 	select {
@@ -738,11 +702,15 @@ func emergencyResetCommand(s *discordgo.Session, m *discordgo.MessageCreate) {
 // pauseCommand pauses the currently playing song
 func pauseCommand(s *discordgo.Session, m *discordgo.MessageCreate) {
 	// Check if anything is currently playing
-	if v.nowPlaying == (Song{}) {
+	if v.nowPlaying == (Track{}) {
 		s.ChannelMessageSend(m.ChannelID, "**[Muse]** ❌ No song is currently playing to pause.")
 		return
 	}
 
+	if !requireSameVoiceChannel(m) {
+		return
+	}
+
 	// Check if already paused
 	if v.paused {
 		s.ChannelMessageSend(m.ChannelID, "**[Muse]** ⏸️ Song is already paused. Use `resume` to continue playback.")
@@ -750,12 +718,8 @@ func pauseCommand(s *discordgo.Session, m *discordgo.MessageCreate) {
 	}
 
 	// Set pause state
-	v.paused = true
-
-	// Set speaking to false to indicate pause
-	if v.voice != nil && v.voice.Ready {
-		v.voice.Speaking(false)
-	}
+	v.setPaused(true)
+	refreshNowPlayingCard()
 
 	s.ChannelMessageSend(m.ChannelID, "**[Muse]** ⏸️ Paused ["+v.nowPlaying.Title+"]")
 	log.Printf("INFO: Paused song: %s", v.nowPlaying.Title)
@@ -764,11 +728,15 @@ func pauseCommand(s *discordgo.Session, m *discordgo.MessageCreate) {
 // resumeCommand resumes the currently paused song
 func resumeCommand(s *discordgo.Session, m *discordgo.MessageCreate) {
 	// Check if anything is currently playing
-	if v.nowPlaying == (Song{}) {
+	if v.nowPlaying == (Track{}) {
 		s.ChannelMessageSend(m.ChannelID, "**[Muse]** ❌ No song is currently playing to resume.")
 		return
 	}
 
+	if !requireSameVoiceChannel(m) {
+		return
+	}
+
 	// Check if not paused
 	if !v.paused {
 		s.ChannelMessageSend(m.ChannelID, "**[Muse]** ▶️ Song is not paused. Use `pause` to pause playback first.")
@@ -776,12 +744,8 @@ func resumeCommand(s *discordgo.Session, m *discordgo.MessageCreate) {
 	}
 
 	// Set resume state
-	v.paused = false
-
-	// Set speaking to true to indicate resume
-	if v.voice != nil && v.voice.Ready {
-		v.voice.Speaking(true)
-	}
+	v.setPaused(false)
+	refreshNowPlayingCard()
 
 	s.ChannelMessageSend(m.ChannelID, "**[Muse]** ▶️ Resumed ["+v.nowPlaying.Title+"]")
 	log.Printf("INFO: Resumed song: %s", v.nowPlaying.Title)
@@ -806,7 +770,7 @@ func historyCommand(s *discordgo.Session, m *discordgo.MessageCreate) {
 	s.ChannelMessageSend(m.ChannelID, "**[Muse]** Fetching song history...")
 
 	// Get recent history (limit to 20 entries for readability)
-	entries, err := historyManager.GetHistory(m.GuildID, 20)
+	entries, err := historyManager.GetHistory(m.GuildID, replayHistoryLimit)
 	if err != nil {
 		log.Printf("ERROR: Failed to get history: %v", err)
 		s.ChannelMessageSend(m.ChannelID, "**[Muse]** ❌ Failed to retrieve history.")
@@ -839,7 +803,7 @@ func historyCommand(s *discordgo.Session, m *discordgo.MessageCreate) {
 		timeStr := formatTimeSince(timeSince)
 
 		songLine := fmt.Sprintf("%d. **%s** (%s)\n   *Played by <@%s> • %s ago*\n\n", 
-			index+1, entry.Song.Title, durationStr, entry.Song.User, timeStr)
+			index+1, entry.Track.Title, durationStr, entry.Track.RequestedBy, timeStr)
 
 		// Check if we need pagination
 		if len(currentMessage)+len(songLine) > maxMessageLength || songsSentInThisMessage >= maxSongsPerMessage {
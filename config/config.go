@@ -40,6 +40,8 @@ type YouTubeConfig struct {
 	RetryDelay        time.Duration `json:"retry_delay"`
 	EnableFallback    bool          `json:"enable_fallback"`
 	FallbackMethod    string        `json:"fallback_method"`
+	DailyQuota        int           `json:"daily_quota"`    // API units available per day
+	CacheTTL          time.Duration `json:"cache_ttl"`      // How long search / playlist API responses are cached on disk
 }
 
 // AudioConfig holds audio processing configuration
@@ -56,6 +58,8 @@ type AudioConfig struct {
 	EnableVBR          bool          `json:"enable_vbr"`
 	ConnectTimeout     time.Duration `json:"connect_timeout"`
 	SpeakingTimeout    time.Duration `json:"speaking_timeout"`
+	IdleTimeout        time.Duration `json:"idle_timeout"`          // How long to stay in voice with nothing to play, 0 to leave right away
+	EmptyChannelTimeout time.Duration `json:"empty_channel_timeout"` // How long to stay paused after everyone left the voice channel, 0 to wait for them
 }
 
 // QueueConfig holds queue management configuration
@@ -66,8 +70,15 @@ type QueueConfig struct {
 	MaxConcurrentPlaylists int          `json:"max_concurrent_playlists"`
 	UserRateLimitDelay    time.Duration `json:"user_rate_limit_delay"`
 	CommandTimeoutDelay   time.Duration `json:"command_timeout_delay"`
-	ShuffleAlgorithm      string        `json:"shuffle_algorithm"`
-	EnableDuplicateCheck  bool          `json:"enable_duplicate_check"`
+	ShuffleAlgorithm      string        `json:"shuffle_algorithm"` // Used by a plain `shuffle`: "fisher-yates" or "smart"
+	ShuffleSeed           int64         `json:"shuffle_seed"`      // Seed for the shuffle RNG, 0 to seed from the clock
+	EnableDuplicateCheck  bool          `json:"enable_duplicate_check"`   // Off makes "allow" the default duplicate policy
+	DuplicatePolicy       string        `json:"duplicate_policy"`         // Default for guilds: "allow", "warn" or "reject"
+	DuplicateWindowMinutes int          `json:"duplicate_window_minutes"` // Default for guilds: songs played this recently count as duplicates
+	FairQueue             bool          `json:"fair_queue"`          // Default for guilds: take turns between requesters
+	MaxTracksPerUser      int           `json:"max_tracks_per_user"` // Default for guilds: songs one user can have queued, 0 for no limit
+	MaxQueuedMinutes      int           `json:"max_queued_minutes"`  // Default for guilds: minutes of music one user can have queued, 0 for no limit
+	MaxTrackMinutes       int           `json:"max_track_minutes"`   // Default for guilds: longest song that can be queued, 0 for no limit
 }
 
 // CacheConfig holds caching configuration
@@ -139,6 +150,8 @@ func DefaultConfig() *Config {
 			RetryDelay:        1 * time.Second,
 			EnableFallback:    true,
 			FallbackMethod:    "yt-dlp",
+			DailyQuota:        10000,
+			CacheTTL:          6 * time.Hour,
 		},
 		Audio: AudioConfig{
 			Bitrate:            128,
@@ -153,6 +166,8 @@ func DefaultConfig() *Config {
 			EnableVBR:          true,
 			ConnectTimeout:     10 * time.Second,
 			SpeakingTimeout:    5 * time.Second,
+			IdleTimeout:        5 * time.Minute,
+			EmptyChannelTimeout: 5 * time.Minute,
 		},
 		Queue: QueueConfig{
 			MaxSize:               500,
@@ -163,6 +178,8 @@ func DefaultConfig() *Config {
 			CommandTimeoutDelay:   2 * time.Second,
 			ShuffleAlgorithm:      "fisher-yates",
 			EnableDuplicateCheck:  true,
+			DuplicatePolicy:       "warn",
+			DuplicateWindowMinutes: 30,
 		},
 		Cache: CacheConfig{
 			CacheDirectory:    "downloads",
@@ -222,6 +239,34 @@ func LoadConfig() (*Config, error) {
 		config.YouTube.APIKey = apiKey
 	}
 
+	if enableFallback := os.Getenv("ENABLE_YOUTUBE_FALLBACK"); enableFallback == "false" {
+		config.YouTube.EnableFallback = false
+	}
+
+	if dailyQuota := os.Getenv("YT_DAILY_QUOTA"); dailyQuota != "" {
+		if quota, err := strconv.Atoi(dailyQuota); err == nil && quota > 0 {
+			config.YouTube.DailyQuota = quota
+		}
+	}
+
+	if cacheTTL := os.Getenv("YT_CACHE_TTL"); cacheTTL != "" {
+		if ttl, err := time.ParseDuration(cacheTTL); err == nil {
+			config.YouTube.CacheTTL = ttl
+		}
+	}
+
+	if idleTimeout := os.Getenv("IDLE_TIMEOUT"); idleTimeout != "" {
+		if timeout, err := time.ParseDuration(idleTimeout); err == nil && timeout >= 0 {
+			config.Audio.IdleTimeout = timeout
+		}
+	}
+
+	if emptyChannelTimeout := os.Getenv("EMPTY_CHANNEL_TIMEOUT"); emptyChannelTimeout != "" {
+		if timeout, err := time.ParseDuration(emptyChannelTimeout); err == nil && timeout >= 0 {
+			config.Audio.EmptyChannelTimeout = timeout
+		}
+	}
+
 	// Load debug mode
 	if debug := os.Getenv("DEBUG"); debug == "true" {
 		config.Logging.Level = "DEBUG"
@@ -240,6 +285,52 @@ func LoadConfig() (*Config, error) {
 		}
 	}
 
+	if shuffleAlgorithm := os.Getenv("SHUFFLE_ALGORITHM"); shuffleAlgorithm != "" {
+		config.Queue.ShuffleAlgorithm = strings.ToLower(shuffleAlgorithm)
+	}
+
+	if shuffleSeed := os.Getenv("SHUFFLE_SEED"); shuffleSeed != "" {
+		if seed, err := strconv.ParseInt(shuffleSeed, 10, 64); err == nil {
+			config.Queue.ShuffleSeed = seed
+		}
+	}
+
+	if duplicateCheck := os.Getenv("ENABLE_DUPLICATE_CHECK"); duplicateCheck != "" {
+		config.Queue.EnableDuplicateCheck = duplicateCheck == "true"
+	}
+
+	if duplicatePolicy := os.Getenv("DUPLICATE_POLICY"); duplicatePolicy != "" {
+		config.Queue.DuplicatePolicy = strings.ToLower(duplicatePolicy)
+	}
+
+	if duplicateWindow := os.Getenv("DUPLICATE_WINDOW_MINUTES"); duplicateWindow != "" {
+		if minutes, err := strconv.Atoi(duplicateWindow); err == nil && minutes >= 0 {
+			config.Queue.DuplicateWindowMinutes = minutes
+		}
+	}
+
+	if fairQueue := os.Getenv("FAIR_QUEUE"); fairQueue == "true" {
+		config.Queue.FairQueue = true
+	}
+
+	if maxTracksPerUser := os.Getenv("MAX_TRACKS_PER_USER"); maxTracksPerUser != "" {
+		if limit, err := strconv.Atoi(maxTracksPerUser); err == nil && limit >= 0 {
+			config.Queue.MaxTracksPerUser = limit
+		}
+	}
+
+	if maxQueuedMinutes := os.Getenv("MAX_QUEUED_MINUTES_PER_USER"); maxQueuedMinutes != "" {
+		if limit, err := strconv.Atoi(maxQueuedMinutes); err == nil && limit >= 0 {
+			config.Queue.MaxQueuedMinutes = limit
+		}
+	}
+
+	if maxTrackMinutes := os.Getenv("MAX_TRACK_MINUTES"); maxTrackMinutes != "" {
+		if limit, err := strconv.Atoi(maxTrackMinutes); err == nil && limit >= 0 {
+			config.Queue.MaxTrackMinutes = limit
+		}
+	}
+
 	if cacheDir := os.Getenv("CACHE_DIR"); cacheDir != "" {
 		config.Cache.CacheDirectory = cacheDir
 	}
@@ -261,6 +352,16 @@ func LoadConfig() (*Config, error) {
 		config.Features.EnableBuffering = false
 	}
 
+	if autoReconnect := os.Getenv("ENABLE_AUTO_RECONNECT"); autoReconnect == "false" {
+		config.Features.EnableAutoReconnect = false
+	}
+
+	if reconnectAttempts := os.Getenv("RECONNECT_ATTEMPTS"); reconnectAttempts != "" {
+		if attempts, err := strconv.Atoi(reconnectAttempts); err == nil && attempts >= 0 {
+			config.Discord.ReconnectAttempts = attempts
+		}
+	}
+
 	if enableMetrics := os.Getenv("ENABLE_METRICS"); enableMetrics == "true" {
 		config.Features.EnableMetrics = true
 	}
@@ -301,9 +402,13 @@ func (c *Config) Validate() error {
 		errors = append(errors, "Discord token (BOT_TOKEN) is required")
 	}
 
-	// Validate YouTube configuration
-	if c.YouTube.APIKey == "" {
-		errors = append(errors, "YouTube API key (YT_TOKEN) is required")
+	// Validate YouTube configuration - without an API key, search runs entirely on the yt-dlp fallback
+	if c.YouTube.APIKey == "" && !c.HasSearchFallback() {
+		errors = append(errors, "YouTube API key (YT_TOKEN) is required unless the yt-dlp search fallback is enabled")
+	}
+
+	if c.YouTube.EnableFallback && c.YouTube.FallbackMethod != "yt-dlp" {
+		errors = append(errors, fmt.Sprintf("unsupported YouTube fallback method %q (only yt-dlp is supported)", c.YouTube.FallbackMethod))
 	}
 
 	// Validate audio configuration
@@ -332,6 +437,16 @@ func (c *Config) Validate() error {
 		errors = append(errors, "max concurrent playlists must be greater than 0")
 	}
 
+	validDuplicatePolicies := []string{"allow", "warn", "reject"}
+	if !contains(validDuplicatePolicies, c.Queue.DuplicatePolicy) {
+		errors = append(errors, fmt.Sprintf("duplicate policy must be one of: %s", strings.Join(validDuplicatePolicies, ", ")))
+	}
+
+	validShuffleAlgorithms := []string{"fisher-yates", "smart"}
+	if !contains(validShuffleAlgorithms, c.Queue.ShuffleAlgorithm) {
+		errors = append(errors, fmt.Sprintf("shuffle algorithm must be one of: %s", strings.Join(validShuffleAlgorithms, ", ")))
+	}
+
 	// Validate cache configuration
 	if c.Cache.MaxCacheSize <= 0 {
 		errors = append(errors, "max cache size must be greater than 0")
@@ -417,8 +532,25 @@ func (c *Config) GetRedactedToken() string {
 	return c.Discord.Token[:8] + "***"
 }
 
+// DefaultDuplicatePolicy returns the duplicate policy for guilds that haven't picked one;
+// turning the duplicate check off means duplicates are allowed
+func (c *Config) DefaultDuplicatePolicy() string {
+	if !c.Queue.EnableDuplicateCheck {
+		return "allow"
+	}
+	return c.Queue.DuplicatePolicy
+}
+
+// HasSearchFallback reports whether searches can fall back to yt-dlp when the YouTube API is unavailable
+func (c *Config) HasSearchFallback() bool {
+	return c.YouTube.EnableFallback && c.YouTube.FallbackMethod == "yt-dlp"
+}
+
 // GetRedactedAPIKey returns a redacted version of the API key for logging
 func (c *Config) GetRedactedAPIKey() string {
+	if c.YouTube.APIKey == "" {
+		return "(not set)"
+	}
 	if len(c.YouTube.APIKey) < 8 {
 		return "***"
 	}
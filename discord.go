@@ -13,7 +13,7 @@ func joinVoiceChannel() {
 	}
 
 	// Find the user's current voice channel dynamically
-	userVoiceChannelID := SearchVoiceChannel(v.currentUserID)
+	userVoiceChannelID := SearchVoiceChannel(v.guildID, v.currentUserID)
 	if userVoiceChannelID == "" {
 		log.Printf("ERROR: User %s is not in any voice channel in guild %s", v.currentUserID, v.guildID)
 		return
@@ -44,7 +44,7 @@ func joinVoiceChannel() {
 // Enhanced version that accepts a specific user ID
 func joinUserVoiceChannel(userID string) error {
 	// Find the specific user's voice channel
-	userVoiceChannelID := SearchVoiceChannel(userID)
+	userVoiceChannelID := SearchVoiceChannel(v.guildID, userID)
 	if userVoiceChannelID == "" {
 		return fmt.Errorf("user %s is not in any voice channel in guild %s", userID, v.guildID)
 	}
@@ -94,7 +94,8 @@ func SearchGuild(textChannelID string) (guildID string) {
 }
 
 // Searches the voice channel (used to look for the person who sent the message & what voice channel they're in)
-func SearchVoiceChannel(user string) (voiceChannelID string) {
+// Only the given guild is searched, being in voice on another server doesn't count
+func SearchVoiceChannel(guildID, user string) (voiceChannelID string) {
 	if user == "" {
 		log.Printf("ERROR: SearchVoiceChannel called with empty user ID")
 		return ""
@@ -105,18 +106,12 @@ func SearchVoiceChannel(user string) (voiceChannelID string) {
 		return ""
 	}
 
-	for _, g := range s.State.Guilds {
-		if g == nil {
-			continue
-		}
-		for _, v := range g.VoiceStates {
-			if v != nil && v.UserID == user {
-				log.Printf("DEBUG: Found user %s in voice channel %s", user, v.ChannelID)
-				return v.ChannelID
-			}
-		}
+	voiceState, err := s.State.VoiceState(guildID, user)
+	if err != nil || voiceState == nil || voiceState.ChannelID == "" {
+		log.Printf("DEBUG: User %s not found in any voice channel in guild %s", user, guildID)
+		return ""
 	}
 
-	log.Printf("DEBUG: User %s not found in any voice channel", user)
-	return ""
+	log.Printf("DEBUG: Found user %s in voice channel %s", user, voiceState.ChannelID)
+	return voiceState.ChannelID
 }
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// Duplicate policies, set per guild with `settings duplicates`
+const (
+	duplicatesAllow  = "allow"  // Queue duplicates without a word
+	duplicatesWarn   = "warn"   // Queue duplicates but say so
+	duplicatesReject = "reject" // Don't queue duplicates
+)
+
+const duplicateTitleSimilarity = 0.8 // Same threshold the download cache uses for similar songs
+
+// duplicateMatch says where a track was already found
+type duplicateMatch struct {
+	Title     string
+	Position  int           // 1-based queue position, 0 when it's playing or was played
+	Playing   bool          // It's the current song
+	PlayedAgo time.Duration // How long ago it was played, when it was found in the history
+}
+
+// Describe explains the match, e.g. "it's already in the queue at position 4"
+func (d duplicateMatch) Describe() string {
+	switch {
+	case d.Playing:
+		return "it's playing right now"
+	case d.Position > 0:
+		return fmt.Sprintf("it's already in the queue at position %d", d.Position)
+	case d.PlayedAgo < time.Minute:
+		return "it was just played"
+	default:
+		return fmt.Sprintf("it was played %s ago", formatTimeSince(d.PlayedAgo))
+	}
+}
+
+// duplicateChecker finds tracks that are already queued, playing or recently played
+type duplicateChecker struct {
+	seen []duplicateCandidate
+}
+
+type duplicateCandidate struct {
+	track Track
+	hash  string
+	match duplicateMatch
+}
+
+// newDuplicateCheckerLocked snapshots the queue, the current song and the guild's history within window;
+// queueMutex must be held
+func newDuplicateCheckerLocked(guildID string, window time.Duration) *duplicateChecker {
+	dc := &duplicateChecker{}
+	if v.nowPlaying != (Track{}) {
+		dc.add(v.nowPlaying, duplicateMatch{Title: v.nowPlaying.Title, Playing: true})
+	}
+	for i, track := range queue {
+		dc.add(track, duplicateMatch{Title: track.Title, Position: i + 1})
+	}
+
+	if historyManager != nil && window > 0 {
+		entries, _ := historyManager.GetHistory(guildID, 0)
+		for _, entry := range entries {
+			ago := time.Since(entry.PlayedAt)
+			if ago > window {
+				break // Most recent first
+			}
+			dc.add(entry.Track, duplicateMatch{Title: entry.Track.Title, PlayedAgo: ago})
+		}
+	}
+	return dc
+}
+
+func (dc *duplicateChecker) add(track Track, match duplicateMatch) {
+	dc.seen = append(dc.seen, duplicateCandidate{track: track, hash: generateTitleHash(track.Title), match: match})
+}
+
+// Find returns where the track, or one with a near-identical title, was already seen
+func (dc *duplicateChecker) Find(track Track) (duplicateMatch, bool) {
+	hash := generateTitleHash(track.Title)
+	for _, candidate := range dc.seen {
+		if dedupeKey(candidate.track) == dedupeKey(track) ||
+			calculateSimilarity(hash, candidate.hash) >= duplicateTitleSimilarity {
+			return candidate.match, true
+		}
+	}
+	return duplicateMatch{}, false
+}
+
+// Admit remembers a track that is being queued, so a playlist can't add the same song twice
+func (dc *duplicateChecker) Admit(track Track, position int) {
+	dc.add(track, duplicateMatch{Title: track.Title, Position: position})
+}
+
+// parseDuplicatePolicy reads a duplicate policy setting
+func parseDuplicatePolicy(value string) (string, bool) {
+	switch value {
+	case duplicatesAllow, "off":
+		return duplicatesAllow, true
+	case duplicatesWarn:
+		return duplicatesWarn, true
+	case duplicatesReject, "block":
+		return duplicatesReject, true
+	}
+	return "", false
+}
+
+// formatDuplicatePolicy renders the duplicate policy setting
+func formatDuplicatePolicy(policy string) string {
+	switch policy {
+	case duplicatesWarn:
+		return "warn"
+	case duplicatesReject:
+		return "reject"
+	}
+	return "allow"
+}
@@ -0,0 +1,43 @@
+package main
+
+// fairOrder interleaves tracks round-robin by requester, keeping each requester's own order.
+// Requesters take turns in the order their first track appears, except that lastRequester
+// (whoever was just played) goes last. Applying it to an already fair queue changes nothing.
+func fairOrder(tracks []Track, lastRequester string) []Track {
+	var requesters []string
+	byRequester := make(map[string][]Track)
+	for _, track := range tracks {
+		if _, seen := byRequester[track.RequestedBy]; !seen {
+			requesters = append(requesters, track.RequestedBy)
+		}
+		byRequester[track.RequestedBy] = append(byRequester[track.RequestedBy], track)
+	}
+
+	// Move the requester who was just played to the back of the rotation
+	for i, requester := range requesters {
+		if requester == lastRequester {
+			requesters = append(append(requesters[:i:i], requesters[i+1:]...), requester)
+			break
+		}
+	}
+
+	ordered := make([]Track, 0, len(tracks))
+	for round := 0; len(ordered) < len(tracks); round++ {
+		for _, requester := range requesters {
+			if round < len(byRequester[requester]) {
+				ordered = append(ordered, byRequester[requester][round])
+			}
+		}
+	}
+	return ordered
+}
+
+// applyFairOrderLocked puts the queue in fair order when the guild has fair queueing on.
+// The queue is reordered in place, so positions shown by `queue` match `remove`, `skip to` etc.
+// queueMutex must be held.
+func applyFairOrderLocked() {
+	if len(queue) < 2 || !settingsFor(v.guildID).FairQueue {
+		return
+	}
+	queue = fairOrder(queue, v.nowPlaying.RequestedBy)
+}
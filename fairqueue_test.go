@@ -0,0 +1,29 @@
+package main
+
+import "testing"
+
+func TestFairOrder(t *testing.T) {
+	tests := []struct {
+		name          string
+		tracks        []Track
+		lastRequester string
+		want          string
+	}{
+		{"empty", nil, "", ""},
+		{"one requester", queuedTracks("u1:Abba", "u1:Blur", "u1:Cher"), "", "abc"},
+		{"one requester just played", queuedTracks("u1:Abba", "u1:Blur"), "u1", "ab"},
+		{"round robin", queuedTracks("u1:Abba", "u1:Blur", "u1:Cher", "u2:Dido", "u3:Enya"), "", "adebc"},
+		{"first come first", queuedTracks("u2:Abba", "u1:Blur", "u2:Cher", "u1:Dido"), "", "abcd"},
+		{"just played goes last", queuedTracks("u1:Abba", "u1:Blur", "u2:Cher", "u2:Dido"), "u1", "cadb"},
+		{"unknown last requester", queuedTracks("u1:Abba", "u2:Blur"), "u3", "ab"},
+		{"already fair", queuedTracks("u1:Abba", "u2:Blur", "u1:Cher", "u2:Dido"), "", "abcd"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := messageIDs(fairOrder(tt.tracks, tt.lastRequester)); got != tt.want {
+				t.Errorf("fairOrder(%q) = %q, want %q", tt.lastRequester, got, tt.want)
+			}
+		})
+	}
+}
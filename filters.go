@@ -0,0 +1,158 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Duration bounds YouTube itself uses for its "short" and "long" search filters
+const (
+	shortVideoLimit = 4 * time.Minute
+	longVideoLimit  = 20 * time.Minute
+)
+
+// SearchOptions holds the flags that can be mixed into a search,
+// e.g. `play! --long --channel lofigirl study beats`
+type SearchOptions struct {
+	First       bool   // Queue the best result right away instead of showing the picker
+	Long        bool   // Only videos longer than 20 minutes
+	Short       bool   // Only videos shorter than 4 minutes
+	Channel     string // Only videos whose channel name contains this (case-insensitive)
+	ExcludeLive bool   // Skip live streams and premieres
+}
+
+// parseSearchArgs splits the arguments after `play` into search options and the search query
+func parseSearchArgs(args []string) (SearchOptions, string, error) {
+	var opts SearchOptions
+	var query []string
+
+	for i := 0; i < len(args); i++ {
+		arg := strings.ToLower(args[i])
+
+		switch arg {
+		case "--first":
+			opts.First = true
+		case "--long":
+			opts.Long = true
+		case "--short":
+			opts.Short = true
+		case "--channel":
+			if i+1 >= len(args) {
+				return opts, "", fmt.Errorf("--channel needs a name, e.g. `--channel lofigirl` or `--channel \"lofi girl\"`")
+			}
+			name, next := quotedValue(args, i+1)
+			opts.Channel = name
+			i = next
+		case "--exclude":
+			if i+1 >= len(args) || strings.ToLower(args[i+1]) != "live" {
+				return opts, "", fmt.Errorf("only `--exclude live` is supported")
+			}
+			opts.ExcludeLive = true
+			i++
+		default:
+			if strings.HasPrefix(arg, "--") {
+				return opts, "", fmt.Errorf("unknown search option %q", args[i])
+			}
+			query = append(query, args[i])
+		}
+	}
+
+	if opts.Long && opts.Short {
+		return opts, "", fmt.Errorf("--long and --short can't be used together")
+	}
+
+	return opts, strings.Join(query, " "), nil
+}
+
+// quotedValue reads an option value starting at args[start], joining words wrapped in double quotes.
+// It returns the value and the index of the last argument consumed.
+func quotedValue(args []string, start int) (string, int) {
+	if !strings.HasPrefix(args[start], "\"") {
+		return args[start], start
+	}
+
+	words := []string{strings.TrimPrefix(args[start], "\"")}
+	end := start
+	for !strings.HasSuffix(words[len(words)-1], "\"") && end+1 < len(args) {
+		end++
+		words = append(words, args[end])
+	}
+	words[len(words)-1] = strings.TrimSuffix(words[len(words)-1], "\"")
+
+	return strings.Join(words, " "), end
+}
+
+// HasFilters reports whether any option narrows down the results
+func (o SearchOptions) HasFilters() bool {
+	return o.Long || o.Short || o.Channel != "" || o.ExcludeLive
+}
+
+// Matches reports whether a search result passes the filters.
+// Results without a known duration can't be shown to be long or short, so those filters drop them.
+func (o SearchOptions) Matches(result SongSearch) bool {
+	if o.ExcludeLive && result.Live {
+		return false
+	}
+
+	if o.Channel != "" && !strings.Contains(strings.ToLower(result.Channel), strings.ToLower(o.Channel)) {
+		return false
+	}
+
+	if o.Long || o.Short {
+		d, err := parseClockDuration(result.Duration)
+		if result.Duration == "" || err != nil {
+			return false
+		}
+		if o.Long && d <= longVideoLimit {
+			return false
+		}
+		if o.Short && d >= shortVideoLimit {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Apply filters the results and moves songs that are already cached to the front,
+// keeping relevance order within the cached and uncached groups
+func (o SearchOptions) Apply(results []SongSearch) []SongSearch {
+	filtered := make([]SongSearch, 0, len(results))
+	for _, result := range results {
+		if o.Matches(result) {
+			filtered = append(filtered, result)
+		}
+	}
+
+	if metadataManager != nil {
+		cached := make(map[string]bool, len(filtered))
+		for _, result := range filtered {
+			cached[result.Id] = metadataManager.HasSong(result.Id)
+		}
+		sort.SliceStable(filtered, func(i, j int) bool {
+			return cached[filtered[i].Id] && !cached[filtered[j].Id]
+		})
+	}
+
+	return filtered
+}
+
+// Describe returns a short human-readable summary of the filters, used in chat messages
+func (o SearchOptions) Describe() string {
+	var parts []string
+	if o.Long {
+		parts = append(parts, "over "+formatDuration(longVideoLimit))
+	}
+	if o.Short {
+		parts = append(parts, "under "+formatDuration(shortVideoLimit))
+	}
+	if o.Channel != "" {
+		parts = append(parts, "channel \""+o.Channel+"\"")
+	}
+	if o.ExcludeLive {
+		parts = append(parts, "no live streams")
+	}
+	return strings.Join(parts, ", ")
+}
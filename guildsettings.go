@@ -0,0 +1,307 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// GuildSettings holds the per-guild options changed with the `settings` command
+type GuildSettings struct {
+	FairQueue        bool `json:"fair_queue"`          // Interleave the queue round-robin by requester
+	MaxTracksPerUser int  `json:"max_tracks_per_user"` // Tracks one user can have waiting in the queue, 0 for no limit
+	MaxQueuedMinutes int  `json:"max_queued_minutes"`  // Total length one user can have waiting in the queue, 0 for no limit
+	MaxTrackMinutes  int  `json:"max_track_minutes"`   // Longest track that can be queued, 0 for no limit
+
+	DuplicatePolicy        string `json:"duplicate_policy"`         // What to do with songs already queued or recently played: allow, warn or reject
+	DuplicateWindowMinutes int    `json:"duplicate_window_minutes"` // Songs played this recently count as duplicates, 0 for only the queue
+}
+
+// GuildSettingsStore keeps the settings of every guild and persists them to disk
+type GuildSettingsStore struct {
+	mutex    sync.RWMutex
+	dataFile string
+	defaults GuildSettings            // Used for guilds that never changed a setting
+	guilds   map[string]GuildSettings // guild_id -> settings
+}
+
+// Global guild settings store (initialized in main.go)
+var guildSettings *GuildSettingsStore
+
+// NewGuildSettingsStore creates a store backed by dataFile, loading any saved settings
+func NewGuildSettingsStore(dataFile string, defaults GuildSettings) *GuildSettingsStore {
+	gs := &GuildSettingsStore{
+		dataFile: dataFile,
+		defaults: defaults,
+		guilds:   make(map[string]GuildSettings),
+	}
+	if err := gs.load(); err != nil {
+		log.Printf("WARN: Failed to load guild settings: %v", err)
+	}
+	return gs
+}
+
+// Get returns the settings for a guild, falling back to the defaults
+func (gs *GuildSettingsStore) Get(guildID string) GuildSettings {
+	gs.mutex.RLock()
+	defer gs.mutex.RUnlock()
+
+	if settings, ok := gs.guilds[guildID]; ok {
+		return settings
+	}
+	return gs.defaults
+}
+
+// Update changes a guild's settings and saves them
+func (gs *GuildSettingsStore) Update(guildID string, change func(*GuildSettings)) (GuildSettings, error) {
+	gs.mutex.Lock()
+	defer gs.mutex.Unlock()
+
+	settings, ok := gs.guilds[guildID]
+	if !ok {
+		settings = gs.defaults
+	}
+	change(&settings)
+	gs.guilds[guildID] = settings
+
+	return settings, gs.saveUnsafe()
+}
+
+// load reads saved settings; a missing file just means nothing was changed yet
+func (gs *GuildSettingsStore) load() error {
+	data, err := os.ReadFile(gs.dataFile)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read guild settings: %w", err)
+	}
+
+	var saved map[string]json.RawMessage
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return fmt.Errorf("failed to parse guild settings: %w", err)
+	}
+
+	gs.mutex.Lock()
+	defer gs.mutex.Unlock()
+	for guildID, raw := range saved {
+		// Start from the defaults so settings added since the file was saved get their default
+		settings := gs.defaults
+		if err := json.Unmarshal(raw, &settings); err != nil {
+			return fmt.Errorf("failed to parse settings for guild %s: %w", guildID, err)
+		}
+		gs.guilds[guildID] = settings
+	}
+
+	log.Printf("INFO: Loaded settings for %d guilds", len(gs.guilds))
+	return nil
+}
+
+// saveUnsafe writes the settings without locking (caller holds the mutex)
+func (gs *GuildSettingsStore) saveUnsafe() error {
+	data, err := json.MarshalIndent(gs.guilds, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal guild settings: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(gs.dataFile), 0755); err != nil {
+		return fmt.Errorf("failed to create guild settings directory: %w", err)
+	}
+	if err := os.WriteFile(gs.dataFile, data, 0644); err != nil {
+		return fmt.Errorf("failed to write guild settings: %w", err)
+	}
+	return nil
+}
+
+// settingsFor returns the settings for a guild, or the zero settings before the store is set up
+func settingsFor(guildID string) GuildSettings {
+	if guildSettings == nil {
+		return GuildSettings{}
+	}
+	return guildSettings.Get(guildID)
+}
+
+// canManageGuild reports whether the message author has the Manage Server permission
+func canManageGuild(s *discordgo.Session, m *discordgo.MessageCreate) bool {
+	permissions, err := s.UserChannelPermissions(m.Author.ID, m.ChannelID)
+	if err != nil {
+		log.Printf("WARN: Failed to check permissions for user %s: %v", m.Author.ID, err)
+		return false
+	}
+	return permissions&(discordgo.PermissionManageServer|discordgo.PermissionAdministrator) != 0
+}
+
+// settingsCommand shows the guild settings, or changes one: `settings <name> <value>`
+func settingsCommand(s *discordgo.Session, m *discordgo.MessageCreate, args []string) {
+	if len(args) == 0 {
+		s.ChannelMessageSend(m.ChannelID, describeGuildSettings(settingsFor(m.GuildID)))
+		return
+	}
+
+	if guildSettings == nil {
+		s.ChannelMessageSend(m.ChannelID, "**[Muse]** ❌ Settings aren't available right now.")
+		return
+	}
+	if !canManageGuild(s, m) {
+		s.ChannelMessageSend(m.ChannelID, "**[Muse]** 🚫 Only members with the Manage Server permission can change settings.")
+		return
+	}
+	if len(args) < 2 {
+		s.ChannelMessageSend(m.ChannelID, "**[Muse]** Usage: `settings <name> <value>`, e.g. `settings fairqueue on`. Type `settings` to see them all.")
+		return
+	}
+
+	name, value := strings.ToLower(args[0]), strings.ToLower(args[1])
+	var change func(*GuildSettings)
+
+	switch name {
+	case "fairqueue":
+		enabled, ok := parseToggle(value)
+		if !ok {
+			s.ChannelMessageSend(m.ChannelID, "**[Muse]** Use `settings fairqueue on` or `settings fairqueue off`.")
+			return
+		}
+		change = func(gs *GuildSettings) { gs.FairQueue = enabled }
+
+	case "usercap":
+		limit, ok := parseLimit(value)
+		if !ok {
+			s.ChannelMessageSend(m.ChannelID, "**[Muse]** Use `settings usercap <number>` or `settings usercap off`.")
+			return
+		}
+		change = func(gs *GuildSettings) { gs.MaxTracksPerUser = limit }
+
+	case "userminutes":
+		limit, ok := parseMinutesLimit(value)
+		if !ok {
+			s.ChannelMessageSend(m.ChannelID, "**[Muse]** Use `settings userminutes <minutes>` (or e.g. `2h`) or `settings userminutes off`.")
+			return
+		}
+		change = func(gs *GuildSettings) { gs.MaxQueuedMinutes = limit }
+
+	case "maxlength":
+		limit, ok := parseMinutesLimit(value)
+		if !ok {
+			s.ChannelMessageSend(m.ChannelID, "**[Muse]** Use `settings maxlength <minutes>` (or e.g. `1h30m`) or `settings maxlength off`.")
+			return
+		}
+		change = func(gs *GuildSettings) { gs.MaxTrackMinutes = limit }
+
+	case "duplicates":
+		policy, ok := parseDuplicatePolicy(value)
+		if !ok {
+			s.ChannelMessageSend(m.ChannelID, "**[Muse]** Use `settings duplicates allow`, `settings duplicates warn` or `settings duplicates reject`.")
+			return
+		}
+		change = func(gs *GuildSettings) { gs.DuplicatePolicy = policy }
+
+	case "duplicatewindow":
+		minutes, ok := parseMinutesLimit(value)
+		if !ok {
+			s.ChannelMessageSend(m.ChannelID, "**[Muse]** Use `settings duplicatewindow <minutes>` (or e.g. `2h`) or `settings duplicatewindow off` to only check the queue.")
+			return
+		}
+		change = func(gs *GuildSettings) { gs.DuplicateWindowMinutes = minutes }
+
+	default:
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("**[Muse]** Unknown setting %q. Type `settings` to see them all.", args[0]))
+		return
+	}
+
+	updated, err := guildSettings.Update(m.GuildID, change)
+	if err != nil {
+		log.Printf("ERROR: Failed to save guild settings: %v", err)
+		s.ChannelMessageSend(m.ChannelID, "**[Muse]** ⚠️ The setting was changed but couldn't be saved, it will reset on restart.")
+	}
+
+	// The queue display follows the effective order, so reorder right away
+	queueMutex.Lock()
+	applyFairOrderLocked()
+	queueMutex.Unlock()
+
+	s.ChannelMessageSend(m.ChannelID, "**[Muse]** ✅ Settings updated.\n"+describeGuildSettings(updated))
+}
+
+// describeGuildSettings renders the settings for chat
+func describeGuildSettings(settings GuildSettings) string {
+	response := "**[Muse]** :gear: **Server Settings** :gear:\n\n"
+	response += fmt.Sprintf("`fairqueue` - Take turns between requesters: **%s**\n", formatToggle(settings.FairQueue))
+	response += fmt.Sprintf("`usercap` - Songs one person can have queued: **%s**\n", formatLimit(settings.MaxTracksPerUser))
+	response += fmt.Sprintf("`userminutes` - Music one person can have queued: **%s**\n", formatMinutesLimit(settings.MaxQueuedMinutes))
+	response += fmt.Sprintf("`maxlength` - Longest song that can be queued: **%s**\n", formatMinutesLimit(settings.MaxTrackMinutes))
+	response += fmt.Sprintf("`duplicates` - Songs already queued or played recently: **%s**\n", formatDuplicatePolicy(settings.DuplicatePolicy))
+	if settings.DuplicateWindowMinutes > 0 {
+		response += fmt.Sprintf("`duplicatewindow` - Played songs count as duplicates for: **%s**\n", formatMinutes(settings.DuplicateWindowMinutes))
+	} else {
+		response += "`duplicatewindow` - Played songs count as duplicates for: **off, only the queue is checked**\n"
+	}
+	response += "\nChange with `settings <name> <value>` (Manage Server permission required)"
+	return response
+}
+
+// parseToggle reads on/off style values
+func parseToggle(value string) (bool, bool) {
+	switch value {
+	case "on", "true", "yes", "enable", "enabled":
+		return true, true
+	case "off", "false", "no", "disable", "disabled":
+		return false, true
+	}
+	return false, false
+}
+
+// parseLimit reads a positive number, or off/none/0 for no limit
+func parseLimit(value string) (int, bool) {
+	if value == "off" || value == "none" {
+		return 0, true
+	}
+	limit, err := strconv.Atoi(value)
+	if err != nil || limit < 0 {
+		return 0, false
+	}
+	return limit, true
+}
+
+// parseMinutesLimit reads a limit in minutes: a plain number of minutes, a duration like "1h30m",
+// or off/none/0 for no limit
+func parseMinutesLimit(value string) (int, bool) {
+	if limit, ok := parseLimit(value); ok {
+		return limit, true
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d < time.Minute {
+		return 0, false
+	}
+	return int(d.Minutes()), true
+}
+
+// formatToggle renders a toggle setting
+func formatToggle(enabled bool) string {
+	if enabled {
+		return "on"
+	}
+	return "off"
+}
+
+// formatMinutesLimit renders a limit in minutes where 0 means no limit
+func formatMinutesLimit(minutes int) string {
+	if minutes <= 0 {
+		return "no limit"
+	}
+	return formatMinutes(minutes)
+}
+
+// formatLimit renders a limit setting where 0 means no limit
+func formatLimit(limit int) string {
+	if limit <= 0 {
+		return "no limit"
+	}
+	return strconv.Itoa(limit)
+}
@@ -13,11 +13,12 @@ import (
 
 // HistoryEntry represents a single played song in the history
 type HistoryEntry struct {
-	Song      Song      `json:"song"`       // The song that was played
-	PlayedAt  time.Time `json:"played_at"`  // When it was played
-	GuildID   string    `json:"guild_id"`   // Which Discord server
-	GuildName string    `json:"guild_name"` // Server name for easier identification
-	Duration  int64     `json:"duration"`   // How long it played (in seconds)
+	Track     Track       `json:"track"`          // The song that was played
+	PlayedAt  time.Time   `json:"played_at"`      // When it was played
+	GuildID   string      `json:"guild_id"`       // Which Discord server
+	GuildName string      `json:"guild_name"`     // Server name for easier identification
+	Duration  int64       `json:"duration"`       // How long it played (in seconds)
+	Legacy    *legacySong `json:"song,omitempty"` // Song as stored by older versions, migrated to Track on load
 }
 
 // GuildHistory represents the history for a specific guild
@@ -72,7 +73,7 @@ func NewHistoryManager(config HistoryConfig) *HistoryManager {
 }
 
 // AddEntry adds a new song to the history for a specific guild
-func (hm *HistoryManager) AddEntry(song Song, guildID, guildName string, duration time.Duration) error {
+func (hm *HistoryManager) AddEntry(song Track, guildID, guildName string, duration time.Duration) error {
 	hm.mutex.Lock()
 	defer hm.mutex.Unlock()
 
@@ -92,7 +93,7 @@ func (hm *HistoryManager) AddEntry(song Song, guildID, guildName string, duratio
 
 	// Create new history entry
 	entry := HistoryEntry{
-		Song:      song,
+		Track:     song,
 		PlayedAt:  time.Now(),
 		GuildID:   guildID,
 		GuildName: guildName,
@@ -256,14 +257,32 @@ func (hm *HistoryManager) LoadHistory() error {
 		return fmt.Errorf("failed to unmarshal history data: %w", err)
 	}
 
-	// Count total entries loaded
+	// Count total entries loaded, migrating entries saved before tracks were typed
 	totalEntries := 0
+	migrated := 0
 	for _, history := range hm.histories {
 		totalEntries += len(history.Entries)
+		for i := range history.Entries {
+			if entry := &history.Entries[i]; entry.Legacy != nil {
+				entry.Track = entry.Legacy.track()
+				entry.Track.RequestedAt = entry.PlayedAt // Not recorded before, playing time is the closest guess
+				entry.Legacy = nil
+				migrated++
+			}
+		}
 	}
 
 	log.Printf("INFO: Loaded history data from %s (%d guilds, %d total entries)", 
 		hm.dataFile, len(hm.histories), totalEntries)
+
+	if migrated > 0 {
+		log.Printf("INFO: Migrated %d history entries to the track format", migrated)
+		go func() {
+			if err := hm.SaveHistory(); err != nil {
+				log.Printf("WARN: Failed to save migrated history: %v", err)
+			}
+		}()
+	}
 	return nil
 }
 
@@ -323,9 +342,9 @@ func (hm *HistoryManager) GetRecentlyPlayedUsers(guildID string, limit int) []st
 	users := make([]string, 0, limit)
 
 	for _, entry := range history.Entries {
-		if !seen[entry.Song.User] {
-			users = append(users, entry.Song.User)
-			seen[entry.Song.User] = true
+		if !seen[entry.Track.RequestedBy] {
+			users = append(users, entry.Track.RequestedBy)
+			seen[entry.Track.RequestedBy] = true
 
 			if len(users) >= limit {
 				break
@@ -350,7 +369,7 @@ func (hm *HistoryManager) FindSimilarSongs(guildID, title string, limit int) []H
 	title = strings.ToLower(title)
 
 	for _, entry := range history.Entries {
-		entryTitle := strings.ToLower(entry.Song.Title)
+		entryTitle := strings.ToLower(entry.Track.Title)
 		if strings.Contains(entryTitle, title) || strings.Contains(title, entryTitle) {
 			matches = append(matches, entry)
 			if len(matches) >= limit {
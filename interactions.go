@@ -0,0 +1,78 @@
+package main
+
+import (
+	"log"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// handleInteraction routes message component interactions (buttons and select menus) to their feature
+func (app *Application) handleInteraction(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	// Only components on guild messages are used, everything else is ignored
+	if i.Type != discordgo.InteractionMessageComponent || i.GuildID == "" {
+		return
+	}
+
+	if app.metrics != nil {
+		app.metrics.RecordDiscordEvent("component_interaction")
+	}
+
+	data := i.MessageComponentData()
+	switch {
+	case strings.HasPrefix(data.CustomID, searchComponentPrefix):
+		app.handleSearchComponent(s, i, data)
+	case strings.HasPrefix(data.CustomID, nowPlayingComponentPrefix):
+		app.handleNowPlayingComponent(s, i, data)
+	case strings.HasPrefix(data.CustomID, queueComponentPrefix):
+		app.handleQueueComponent(s, i, data)
+	default:
+		log.Printf("WARN: Unknown component interaction: %s", data.CustomID)
+		respondEphemeral(s, i, "**[Muse]** This control is no longer active.")
+	}
+}
+
+// interactionUser returns the user who triggered an interaction
+func interactionUser(i *discordgo.InteractionCreate) *discordgo.User {
+	if i.Member != nil && i.Member.User != nil {
+		return i.Member.User
+	}
+	return i.User
+}
+
+// componentMessage wraps a component interaction as a message so the text command handlers can run it
+func componentMessage(i *discordgo.InteractionCreate, content string) *discordgo.MessageCreate {
+	return &discordgo.MessageCreate{
+		Message: &discordgo.Message{
+			ID:        i.ID,
+			ChannelID: i.ChannelID,
+			GuildID:   i.GuildID,
+			Author:    interactionUser(i),
+			Content:   content,
+		},
+	}
+}
+
+// acknowledgeComponent tells Discord the interaction was received; the message is edited separately
+func acknowledgeComponent(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredMessageUpdate,
+	})
+	if err != nil {
+		log.Printf("WARN: Failed to acknowledge interaction %s: %v", i.ID, err)
+	}
+}
+
+// respondEphemeral answers an interaction with a message only the clicking user can see
+func respondEphemeral(s *discordgo.Session, i *discordgo.InteractionCreate, content string) {
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: content,
+			Flags:   discordgo.MessageFlagsEphemeral,
+		},
+	})
+	if err != nil {
+		log.Printf("WARN: Failed to respond to interaction %s: %v", i.ID, err)
+	}
+}
@@ -0,0 +1,295 @@
+package audio
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"layeh.com/gopus"
+)
+
+// ErrVoiceLost is returned when the voice connection stopped taking audio in the middle of a track
+var ErrVoiceLost = errors.New("voice connection stopped taking audio")
+
+// errSeek ends the current ffmpeg run so the engine can start a new one at the seek position
+var errSeek = errors.New("seek requested")
+
+const (
+	channels         = 2               // Stereo
+	frameSendTimeout = 1 * time.Second // A frame the connection doesn't take within this means it dropped
+)
+
+// Engine plays a track on a voice connection: decode, filter, encode, pace and send. Play blocks
+// until the track ends, ctx is cancelled or sending fails, and returns the position of the last
+// frame that was sent. The Playback handle pauses, seeks and changes the volume while it runs.
+type Engine interface {
+	Play(ctx context.Context, voice *discordgo.VoiceConnection, track *Track, playback *Playback) (time.Duration, error)
+}
+
+// Playback steers a running track. Pausing stops reading audio, so a track resumes exactly
+// where it paused.
+type Playback struct {
+	mu       sync.Mutex
+	resume   chan struct{} // Closed while playing, open while paused
+	volume   int           // 256 leaves the audio as it is
+	seek     chan time.Duration
+	position time.Duration
+}
+
+func newPlayback(volume int) *Playback {
+	resume := make(chan struct{})
+	close(resume)
+	return &Playback{
+		resume: resume,
+		volume: volume,
+		seek:   make(chan time.Duration, 1),
+	}
+}
+
+// Pause holds the track at its current position
+func (p *Playback) Pause() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	select {
+	case <-p.resume:
+		p.resume = make(chan struct{})
+	default: // Already paused
+	}
+}
+
+// Resume continues a paused track
+func (p *Playback) Resume() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	select {
+	case <-p.resume: // Not paused
+	default:
+		close(p.resume)
+	}
+}
+
+// Paused reports whether the track is paused
+func (p *Playback) Paused() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	select {
+	case <-p.resume:
+		return false
+	default:
+		return true
+	}
+}
+
+// waitWhilePaused blocks while the track is paused; it returns ctx's error when cancelled meanwhile
+func (p *Playback) waitWhilePaused(ctx context.Context) error {
+	p.mu.Lock()
+	resume := p.resume
+	p.mu.Unlock()
+
+	select {
+	case <-resume:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// SetVolume changes the volume of the running track, 256 leaves the audio as it is
+func (p *Playback) SetVolume(volume int) {
+	p.mu.Lock()
+	p.volume = volume
+	p.mu.Unlock()
+}
+
+func (p *Playback) currentVolume() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.volume
+}
+
+// Seek jumps to a position in the track; a newer seek replaces one that wasn't picked up yet
+func (p *Playback) Seek(position time.Duration) {
+	select {
+	case <-p.seek:
+	default:
+	}
+	p.seek <- position
+}
+
+// Position returns how far into the track the last frame sent was
+func (p *Playback) Position() time.Duration {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.position
+}
+
+func (p *Playback) setPosition(position time.Duration) {
+	p.mu.Lock()
+	p.position = position
+	p.mu.Unlock()
+}
+
+// ffmpegEngine decodes and filters with ffmpeg, encodes to Opus with gopus and lets the voice
+// connection's send loop pace the frames
+type ffmpegEngine struct {
+	config Config
+}
+
+func newFFmpegEngine(config Config) *ffmpegEngine {
+	if config.FrameRate == 0 {
+		config.FrameRate = 48000 // Discord's sample rate
+	}
+	if config.FrameDuration == 0 {
+		config.FrameDuration = 20
+	}
+	if config.Bitrate == 0 {
+		config.Bitrate = 128
+	}
+	return &ffmpegEngine{config: config}
+}
+
+// Play implements Engine; a seek restarts ffmpeg at the new position within the same call
+func (e *ffmpegEngine) Play(ctx context.Context, voice *discordgo.VoiceConnection, track *Track, playback *Playback) (time.Duration, error) {
+	voice.Speaking(true)
+	defer voice.Speaking(false)
+
+	start := track.Start
+	for {
+		seekTo, err := e.playFrom(ctx, voice, track, playback, start)
+		if errors.Is(err, errSeek) {
+			start = seekTo
+			continue
+		}
+		return playback.Position(), err
+	}
+}
+
+// playFrom runs one ffmpeg pass from start until the end of the track, a seek or an error
+func (e *ffmpegEngine) playFrom(ctx context.Context, voice *discordgo.VoiceConnection, track *Track, playback *Playback, start time.Duration) (time.Duration, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel() // Also kills ffmpeg when it is still writing
+
+	cmd := exec.CommandContext(ctx, "ffmpeg", e.ffmpegArgs(track, start)...)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return 0, fmt.Errorf("failed to create ffmpeg stdout pipe: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return 0, fmt.Errorf("failed to start ffmpeg: %w", err)
+	}
+	defer cmd.Wait()
+
+	encoder, err := gopus.NewEncoder(e.config.FrameRate, channels, gopus.Audio)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create opus encoder: %w", err)
+	}
+	encoder.SetBitrate(e.config.Bitrate * 1000)
+
+	frameSize := e.config.FrameRate * e.config.FrameDuration / 1000
+	frameDuration := time.Duration(e.config.FrameDuration) * time.Millisecond
+	pcm := make([]int16, frameSize*channels)
+	reader := bufio.NewReaderSize(stdout, 16384)
+	position := start
+	playback.setPosition(position)
+
+	sendTimer := time.NewTimer(frameSendTimeout)
+	defer sendTimer.Stop()
+
+	for {
+		if playback.Paused() {
+			voice.Speaking(false)
+			if err := playback.waitWhilePaused(ctx); err != nil {
+				return 0, err
+			}
+			voice.Speaking(true)
+		}
+
+		select {
+		case seekTo := <-playback.seek:
+			return seekTo, errSeek
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		default:
+		}
+
+		// Decode
+		if err := binary.Read(reader, binary.LittleEndian, pcm); err != nil {
+			if ctx.Err() != nil {
+				return 0, ctx.Err()
+			}
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				return 0, nil // End of the track
+			}
+			return 0, fmt.Errorf("failed to read from ffmpeg: %w", err)
+		}
+
+		// Filter
+		applyVolume(pcm, playback.currentVolume())
+
+		// Encode
+		frame, err := encoder.Encode(pcm, frameSize, len(pcm)*2)
+		if err != nil {
+			return 0, fmt.Errorf("failed to encode opus frame: %w", err)
+		}
+
+		// Pace and send: the connection takes a frame every frame duration, a dropped one
+		// stops taking them
+		sendTimer.Reset(frameSendTimeout)
+		select {
+		case voice.OpusSend <- frame:
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		case <-sendTimer.C:
+			return 0, ErrVoiceLost
+		}
+		if !sendTimer.Stop() {
+			select {
+			case <-sendTimer.C:
+			default:
+			}
+		}
+
+		position += frameDuration
+		playback.setPosition(position)
+	}
+}
+
+// ffmpegArgs decodes the track from start to raw PCM, through the track's filters
+func (e *ffmpegEngine) ffmpegArgs(track *Track, start time.Duration) []string {
+	args := []string{"-hide_banner", "-loglevel", "error"}
+	if start > 0 {
+		// Input seeking (before -i) is fast and accurate for MP3
+		args = append(args, "-ss", fmt.Sprintf("%.3f", start.Seconds()))
+	}
+	args = append(args, "-i", track.URL)
+	if len(track.Filters) > 0 {
+		args = append(args, "-af", strings.Join(track.Filters, ","))
+	}
+	return append(args,
+		"-f", "s16le",
+		"-ar", fmt.Sprint(e.config.FrameRate),
+		"-ac", fmt.Sprint(channels),
+		"pipe:1")
+}
+
+// applyVolume scales PCM samples, 256 leaves them as they are
+func applyVolume(pcm []int16, volume int) {
+	if volume == 256 {
+		return
+	}
+	for i, sample := range pcm {
+		scaled := int32(sample) * int32(volume) / 256
+		pcm[i] = int16(max(min(scaled, 32767), -32768))
+	}
+}
@@ -2,12 +2,12 @@ package audio
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sync"
 	"time"
 
 	"github.com/bwmarrin/discordgo"
-	"github.com/jonas747/dca"
 )
 
 // Manager handles audio operations
@@ -15,6 +15,7 @@ type Manager struct {
 	mu       sync.RWMutex
 	sessions map[string]*Session
 	config   Config
+	engine   Engine
 }
 
 // Config holds audio configuration
@@ -37,8 +38,8 @@ type Session struct {
 	guildID       string
 	channelID     string
 	voice         *discordgo.VoiceConnection
-	encoder       *dca.EncodeSession
-	stream        *dca.StreamingSession
+	playback      *Playback          // The running track, nil when nothing plays
+	cancel        context.CancelFunc // Stops the running track
 	currentTrack  *Track
 	state         State
 	volume        int
@@ -54,9 +55,18 @@ type Track struct {
 	Title    string
 	URL      string
 	Duration time.Duration
+	Start    time.Duration // Position to start playing from
+	Filters  []string      // ffmpeg audio filters, applied in order
 	Metadata map[string]interface{}
 }
 
+// Result says how playback of a track ended
+type Result struct {
+	Position time.Duration // How far into the track the last frame sent was
+	Stopped  bool          // Stopped before the end, by Stop or by cancelling the context
+	Err      error         // Why playback failed, ErrVoiceLost when the voice connection dropped
+}
+
 // State represents the playback state
 type State string
 
@@ -83,6 +93,7 @@ func NewManager(config Config) *Manager {
 	return &Manager{
 		sessions: make(map[string]*Session),
 		config:   config,
+		engine:   newFFmpegEngine(config),
 	}
 }
 
@@ -198,102 +209,111 @@ func (m *Manager) LeaveChannel(guildID string) error {
 	return nil
 }
 
-// Play plays a track
-func (m *Manager) Play(ctx context.Context, guildID string, track *Track) error {
+// Play starts a track on the given voice connection, or the session's own when voice is nil,
+// stopping whatever was playing. The returned channel gets the result once the track ends.
+func (m *Manager) Play(ctx context.Context, guildID string, voice *discordgo.VoiceConnection, track *Track) (<-chan Result, error) {
 	audioSession, err := m.GetSession(guildID)
 	if err != nil {
-		return fmt.Errorf("failed to get audio session: %w", err)
+		return nil, fmt.Errorf("failed to get audio session: %w", err)
 	}
 	
 	audioSession.mu.Lock()
 	defer audioSession.mu.Unlock()
 	
-	if audioSession.voice == nil {
-		return fmt.Errorf("not connected to a voice channel")
+	if voice == nil {
+		voice = audioSession.voice
+	}
+	if voice == nil || !voice.Ready {
+		return nil, fmt.Errorf("not connected to a voice channel")
 	}
 	
 	// Stop current playback if any
-	if audioSession.encoder != nil {
-		audioSession.encoder.Cleanup()
-		audioSession.encoder = nil
-	}
-	
-	if audioSession.stream != nil {
-		audioSession.stream.Finished()
-		audioSession.stream = nil
-	}
-	
-	// Create DCA encoder options
-	options := dca.StdEncodeOptions
-	options.RawOutput = false
-	options.Bitrate = m.config.Bitrate
-	options.Application = dca.AudioApplicationAudio
-	options.Volume = audioSession.volume
-	options.CompressionLevel = m.config.CompressionLevel
-	options.FrameRate = m.config.FrameRate
-	options.FrameDuration = m.config.FrameDuration
-	options.PacketLoss = m.config.PacketLoss
-	options.VBR = m.config.EnableVBR
-	options.BufferedFrames = m.config.BufferedFrames
-	
-	// Create encoder session
-	encoder, err := dca.EncodeFile(track.URL, options)
-	if err != nil {
-		audioSession.setState(StateError)
-		return fmt.Errorf("failed to create encoder: %w", err)
+	if audioSession.cancel != nil {
+		audioSession.cancel()
 	}
 	
-	audioSession.encoder = encoder
+	playCtx, cancel := context.WithCancel(ctx)
+	playback := newPlayback(audioSession.volume)
+	audioSession.playback = playback
+	audioSession.cancel = cancel
 	audioSession.currentTrack = track
+	audioSession.paused = false
 	audioSession.setState(StatePlaying)
 	
-	// Start playback in goroutine
+	done := make(chan Result, 1)
 	go func() {
+		result := Result{}
 		defer func() {
 			if r := recover(); r != nil {
-				audioSession.setState(StateError)
+				result.Err = fmt.Errorf("playback panicked: %v", r)
 			}
-		}()
-		
-		// Create streaming session
-		stream := dca.NewStream(encoder, audioSession.voice, nil)
-		audioSession.stream = stream
-		
-		// Set speaking
-		audioSession.voice.Speaking(true)
-		audioSession.speaking = true
-		
-		// Wait for playback to finish
-		go func() {
-			// Monitor stream for completion
-			for {
-				select {
-				case <-ctx.Done():
-					return
-				case <-time.After(100 * time.Millisecond):
-					if audioSession.paused {
-						continue
-					}
-					// Check if encoder is still running
-					if encoder.Running() {
-						continue
-					}
-					// Encoder finished
-					audioSession.mu.Lock()
-					audioSession.voice.Speaking(false)
-					audioSession.speaking = false
+			cancel()
+	
+			audioSession.mu.Lock()
+			if audioSession.playback == playback {
+				audioSession.playback = nil
+				audioSession.cancel = nil
+				audioSession.currentTrack = nil
+				audioSession.paused = false
+				switch {
+				case result.Err != nil:
+					audioSession.setState(StateError)
+				case result.Stopped:
+					audioSession.setState(StateStopped)
+				default:
 					audioSession.setState(StateIdle)
-					audioSession.currentTrack = nil
-					audioSession.mu.Unlock()
-					return
 				}
 			}
+			audioSession.mu.Unlock()
+	
+			done <- result
 		}()
+	
+		position, err := m.engine.Play(playCtx, voice, track, playback)
+		result.Position = position
+		if errors.Is(err, context.Canceled) {
+			result.Stopped = true
+		} else {
+			result.Err = err
+		}
 	}()
 	
+	return done, nil
+}
+
+// Seek jumps to a position in the playing track
+func (m *Manager) Seek(guildID string, position time.Duration) error {
+	audioSession, err := m.GetSession(guildID)
+	if err != nil {
+		return fmt.Errorf("failed to get audio session: %w", err)
+	}
+	
+	audioSession.mu.RLock()
+	defer audioSession.mu.RUnlock()
+	
+	if audioSession.playback == nil {
+		return fmt.Errorf("not playing")
+	}
+	audioSession.playback.Seek(position)
 	return nil
 }
 
+// Position returns how far into the playing track the last frame sent was
+func (m *Manager) Position(guildID string) time.Duration {
+	audioSession, err := m.GetSession(guildID)
+	if err != nil {
+		return 0
+	}
+	
+	audioSession.mu.RLock()
+	defer audioSession.mu.RUnlock()
+	
+	if audioSession.playback == nil {
+		return 0
+	}
+	return audioSession.playback.Position()
+}
+
 // Pause pauses playback
 func (m *Manager) Pause(guildID string) error {
 	audioSession, err := m.GetSession(guildID)
@@ -309,13 +329,9 @@ func (m *Manager) Pause(guildID string) error {
 	}
 	
 	audioSession.paused = true
+	audioSession.playback.Pause()
 	audioSession.setState(StatePaused)
 	
-	if audioSession.voice != nil {
-		audioSession.voice.Speaking(false)
-		audioSession.speaking = false
-	}
-	
 	return nil
 }
 
@@ -334,13 +350,9 @@ func (m *Manager) Resume(guildID string) error {
 	}
 	
 	audioSession.paused = false
+	audioSession.playback.Resume()
 	audioSession.setState(StatePlaying)
 	
-	if audioSession.voice != nil {
-		audioSession.voice.Speaking(true)
-		audioSession.speaking = true
-	}
-	
 	return nil
 }
 
@@ -354,20 +366,11 @@ func (m *Manager) Stop(guildID string) error {
 	audioSession.mu.Lock()
 	defer audioSession.mu.Unlock()
 	
-	if audioSession.encoder != nil {
-		audioSession.encoder.Cleanup()
-		audioSession.encoder = nil
-	}
-	
-	if audioSession.stream != nil {
-		audioSession.stream.Finished()
-		audioSession.stream = nil
-	}
-	
-	if audioSession.voice != nil {
-		audioSession.voice.Speaking(false)
-		audioSession.speaking = false
+	if audioSession.cancel != nil {
+		audioSession.cancel()
+		audioSession.cancel = nil
 	}
+	audioSession.playback = nil
 	
 	audioSession.paused = false
 	audioSession.currentTrack = nil
@@ -391,6 +394,9 @@ func (m *Manager) SetVolume(guildID string, volume int) error {
 	defer audioSession.mu.Unlock()
 	
 	audioSession.volume = volume
+	if audioSession.playback != nil {
+		audioSession.playback.SetVolume(volume)
+	}
 	
 	return nil
 }
@@ -460,7 +466,7 @@ func (m *Manager) CleanupInactiveSessions(maxAge time.Duration) {
 	for guildID, session := range m.sessions {
 		session.mu.RLock()
 		lastActivity := session.lastActivity
-		isActive := session.voice != nil && session.state == StatePlaying
+		isActive := session.playback != nil || (session.voice != nil && session.state == StatePlaying)
 		session.mu.RUnlock()
 		
 		if !isActive && lastActivity.Before(cutoff) {
@@ -469,11 +475,8 @@ func (m *Manager) CleanupInactiveSessions(maxAge time.Duration) {
 			if session.voice != nil {
 				session.voice.Disconnect()
 			}
-			if session.encoder != nil {
-				session.encoder.Cleanup()
-			}
-			if session.stream != nil {
-				session.stream.Finished()
+			if session.cancel != nil {
+				session.cancel()
 			}
 			session.mu.Unlock()
 			
@@ -533,11 +536,8 @@ func (m *Manager) Shutdown() error {
 		if session.voice != nil {
 			session.voice.Disconnect()
 		}
-		if session.encoder != nil {
-			session.encoder.Cleanup()
-		}
-		if session.stream != nil {
-			session.stream.Finished()
+		if session.cancel != nil {
+			session.cancel()
 		}
 		session.mu.Unlock()
 	}
@@ -0,0 +1,82 @@
+package ytapi
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// responseCache stores API responses as JSON files, one per method and request
+type responseCache struct {
+	dir string
+	ttl time.Duration
+}
+
+// cacheEntry is the on-disk form of a cached response
+type cacheEntry struct {
+	StoredAt time.Time       `json:"stored_at"`
+	Method   string          `json:"method"`
+	Key      string          `json:"key"`
+	Response json.RawMessage `json:"response"`
+}
+
+// newResponseCache returns a cache in dir, or nil (caching disabled) when ttl isn't positive
+func newResponseCache(dir string, ttl time.Duration) *responseCache {
+	if ttl <= 0 {
+		return nil
+	}
+	return &responseCache{dir: dir, ttl: ttl}
+}
+
+// path returns the file holding the response for a method and request key
+func (rc *responseCache) path(method, key string) string {
+	sum := sha1.Sum([]byte(method + "\n" + key))
+	return filepath.Join(rc.dir, method+"-"+hex.EncodeToString(sum[:])+".json")
+}
+
+// get decodes a fresh cached response into out and reports whether one was found
+func (rc *responseCache) get(method, key string, out interface{}) bool {
+	if rc == nil {
+		return false
+	}
+
+	data, err := os.ReadFile(rc.path(method, key))
+	if err != nil {
+		return false
+	}
+
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil || entry.Key != key {
+		return false
+	}
+	if time.Since(entry.StoredAt) > rc.ttl {
+		os.Remove(rc.path(method, key))
+		return false
+	}
+
+	return json.Unmarshal(entry.Response, out) == nil
+}
+
+// put stores a response; failures only cost a future cache miss, so they are ignored
+func (rc *responseCache) put(method, key string, response interface{}) {
+	if rc == nil {
+		return
+	}
+
+	raw, err := json.Marshal(response)
+	if err != nil {
+		return
+	}
+	data, err := json.Marshal(cacheEntry{StoredAt: time.Now(), Method: method, Key: key, Response: raw})
+	if err != nil {
+		return
+	}
+
+	if err := os.MkdirAll(rc.dir, 0755); err != nil {
+		return
+	}
+	os.WriteFile(rc.path(method, key), data, 0644)
+}
@@ -0,0 +1,63 @@
+package ytapi
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+)
+
+type cachedValue struct {
+	Title string
+}
+
+func TestResponseCacheTTL(t *testing.T) {
+	rc := newResponseCache(t.TempDir(), time.Hour)
+	rc.put(MethodSearch, "q=lofi", cachedValue{Title: "lofi"})
+
+	var got cachedValue
+	if !rc.get(MethodSearch, "q=lofi", &got) || got.Title != "lofi" {
+		t.Fatalf("fresh entry: got %+v, want a hit", got)
+	}
+	if rc.get(MethodSearch, "q=jazz", &got) {
+		t.Error("hit for a key that was never stored")
+	}
+
+	// Age the entry past the TTL
+	path := rc.path(MethodSearch, "q=lofi")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		t.Fatal(err)
+	}
+	entry.StoredAt = time.Now().Add(-2 * time.Hour)
+	if data, err = json.Marshal(entry); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if rc.get(MethodSearch, "q=lofi", &got) {
+		t.Error("hit for an expired entry")
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("expired entry wasn't removed: %v", err)
+	}
+}
+
+func TestResponseCacheDisabled(t *testing.T) {
+	rc := newResponseCache(t.TempDir(), 0)
+	if rc != nil {
+		t.Fatal("cache enabled without a TTL")
+	}
+	rc.put(MethodSearch, "q=lofi", cachedValue{Title: "lofi"})
+
+	var got cachedValue
+	if rc.get(MethodSearch, "q=lofi", &got) {
+		t.Error("hit from a disabled cache")
+	}
+}
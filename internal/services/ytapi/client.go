@@ -0,0 +1,416 @@
+package ytapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/youtube/v3"
+)
+
+// Quota cost of each method in units, from the YouTube Data API documentation
+var methodCosts = map[string]int{
+	MethodSearch:        100,
+	MethodVideos:        1,
+	MethodPlaylistItems: 1,
+}
+
+// Methods the client wraps
+const (
+	MethodSearch        = "search.list"
+	MethodVideos        = "videos.list"
+	MethodPlaylistItems = "playlistItems.list"
+)
+
+// ErrQuotaExhausted is returned without calling the API once the daily quota is used up
+var ErrQuotaExhausted = errors.New("youtube api daily quota exhausted")
+
+// Config holds the client configuration
+type Config struct {
+	DailyQuota     int           // Units available per day (10000 for a default project)
+	RetryAttempts  int           // Attempts per call for transient failures (at least 1)
+	RetryDelay     time.Duration // Delay before the first retry, doubled for each further retry
+	RequestTimeout time.Duration // Timeout for a single attempt
+	CacheDir       string        // Directory for cached responses and the quota ledger, empty disables both
+	CacheTTL       time.Duration // How long cached search / playlist responses stay valid
+
+	// Optional hooks for metrics
+	OnRequest  func(method string, units int, usedToday int)
+	OnCacheHit func(method string)
+}
+
+// MethodUsage holds today's numbers for one API method
+type MethodUsage struct {
+	Calls     int `json:"calls"`      // Requests sent, including retries
+	Units     int `json:"units"`      // Quota units spent
+	CacheHits int `json:"cache_hits"` // Calls answered from the disk cache
+}
+
+// Usage is a snapshot of today's quota usage
+type Usage struct {
+	Day       string                 // Pacific-time date the quota belongs to
+	Used      int                    // Units spent today
+	Limit     int                    // Daily quota
+	ResetsAt  time.Time              // Next quota reset
+	Exhausted bool                   // Whether calls are currently refused
+	Methods   map[string]MethodUsage // Usage per method
+}
+
+// MethodNames returns the method names of the snapshot in a stable order
+func (u Usage) MethodNames() []string {
+	names := make([]string, 0, len(u.Methods))
+	for name := range u.Methods {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// quotaLedger is the persisted form of today's usage, so restarts don't forget spent quota
+type quotaLedger struct {
+	Day       string                 `json:"day"`
+	Exhausted bool                   `json:"exhausted"`
+	Methods   map[string]MethodUsage `json:"methods"`
+}
+
+// Client wraps the YouTube Data API service with quota accounting, response caching and retries
+type Client struct {
+	service *youtube.Service
+	config  Config
+	cache   *responseCache
+
+	mu     sync.Mutex
+	ledger quotaLedger
+}
+
+// NewClient creates a client for the given service
+func NewClient(service *youtube.Service, config Config) *Client {
+	if config.RetryAttempts < 1 {
+		config.RetryAttempts = 1
+	}
+	if config.DailyQuota <= 0 {
+		config.DailyQuota = 10000
+	}
+
+	c := &Client{
+		service: service,
+		config:  config,
+		ledger:  quotaLedger{Day: quotaDay(time.Now()), Methods: make(map[string]MethodUsage)},
+	}
+	if config.CacheDir != "" {
+		c.cache = newResponseCache(filepath.Join(config.CacheDir, "youtube-api"), config.CacheTTL)
+		c.loadLedger()
+	}
+	return c
+}
+
+// Search runs search.list for videos. videoDuration is "", "short", "medium" or "long".
+func (c *Client) Search(ctx context.Context, query, videoDuration, pageToken string, maxResults int64) (*youtube.SearchListResponse, error) {
+	key := fmt.Sprintf("q=%s|duration=%s|page=%s|max=%d", query, videoDuration, pageToken, maxResults)
+	response := &youtube.SearchListResponse{}
+	if c.cache.get(MethodSearch, key, response) {
+		c.recordCacheHit(MethodSearch)
+		return response, nil
+	}
+
+	err := c.do(ctx, MethodSearch, func(ctx context.Context) error {
+		call := c.service.Search.List([]string{"id", "snippet"}).
+			Q(query).
+			Type("video").
+			MaxResults(maxResults).
+			Context(ctx)
+		if pageToken != "" {
+			call = call.PageToken(pageToken)
+		}
+		if videoDuration != "" {
+			call = call.VideoDuration(videoDuration)
+		}
+
+		var err error
+		response, err = call.Do()
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	c.cache.put(MethodSearch, key, response)
+	return response, nil
+}
+
+// Videos runs videos.list for the given IDs and parts. Responses aren't cached, they're cheap and change often.
+func (c *Client) Videos(ctx context.Context, parts []string, ids []string) (*youtube.VideoListResponse, error) {
+	var response *youtube.VideoListResponse
+	err := c.do(ctx, MethodVideos, func(ctx context.Context) error {
+		var err error
+		response, err = c.service.Videos.List(parts).Id(ids...).Context(ctx).Do()
+		return err
+	})
+	return response, err
+}
+
+// PlaylistItems runs playlistItems.list for one page of a playlist
+func (c *Client) PlaylistItems(ctx context.Context, parts []string, playlistID, pageToken string) (*youtube.PlaylistItemListResponse, error) {
+	key := fmt.Sprintf("list=%s|parts=%v|page=%s", playlistID, parts, pageToken)
+	response := &youtube.PlaylistItemListResponse{}
+	if c.cache.get(MethodPlaylistItems, key, response) {
+		c.recordCacheHit(MethodPlaylistItems)
+		return response, nil
+	}
+
+	err := c.do(ctx, MethodPlaylistItems, func(ctx context.Context) error {
+		call := c.service.PlaylistItems.List(parts).
+			PlaylistId(playlistID).
+			Context(ctx)
+		if pageToken != "" {
+			call = call.PageToken(pageToken)
+		}
+
+		var err error
+		response, err = call.Do()
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	c.cache.put(MethodPlaylistItems, key, response)
+	return response, nil
+}
+
+// Usage returns a snapshot of today's quota usage
+func (c *Client) Usage() Usage {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.rollDay()
+	usage := Usage{
+		Day:       c.ledger.Day,
+		Limit:     c.config.DailyQuota,
+		ResetsAt:  NextQuotaReset(time.Now()),
+		Exhausted: c.ledger.Exhausted,
+		Methods:   make(map[string]MethodUsage, len(c.ledger.Methods)),
+	}
+	for method, m := range c.ledger.Methods {
+		usage.Methods[method] = m
+		usage.Used += m.Units
+	}
+	return usage
+}
+
+// do runs an API call with quota accounting and retries transient failures with exponential backoff.
+// The cost is booked once per call, retries only count as requests.
+func (c *Client) do(ctx context.Context, method string, call func(ctx context.Context) error) error {
+	if !c.reserve(method) {
+		return ErrQuotaExhausted
+	}
+
+	var err error
+	delay := c.config.RetryDelay
+
+	for attempt := 1; attempt <= c.config.RetryAttempts; attempt++ {
+		if attempt > 1 {
+			c.recordRetry(method)
+		}
+
+		attemptCtx, cancel := ctx, context.CancelFunc(func() {})
+		if c.config.RequestTimeout > 0 {
+			attemptCtx, cancel = context.WithTimeout(ctx, c.config.RequestTimeout)
+		}
+		err = call(attemptCtx)
+		cancel()
+
+		if err == nil {
+			return nil
+		}
+		if IsQuotaError(err) {
+			c.markExhausted()
+			return err
+		}
+		if !isTransient(err) || attempt == c.config.RetryAttempts {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+
+	return err
+}
+
+// reserve books the cost of one call, refusing it if the quota is exhausted or the call wouldn't fit
+func (c *Client) reserve(method string) bool {
+	cost := methodCosts[method]
+
+	c.mu.Lock()
+	c.rollDay()
+	if c.ledger.Exhausted || c.usedLocked()+cost > c.config.DailyQuota {
+		c.mu.Unlock()
+		return false
+	}
+
+	usage := c.ledger.Methods[method]
+	usage.Calls++
+	usage.Units += cost
+	c.ledger.Methods[method] = usage
+	used := c.usedLocked()
+	c.saveLedgerLocked()
+	c.mu.Unlock()
+
+	if c.config.OnRequest != nil {
+		c.config.OnRequest(method, cost, used)
+	}
+	return true
+}
+
+// markExhausted stops further calls until the quota resets, after the API reported it used up
+func (c *Client) markExhausted() {
+	c.mu.Lock()
+	c.ledger.Exhausted = true
+	c.saveLedgerLocked()
+	c.mu.Unlock()
+}
+
+// recordRetry counts a retried request, whose cost was already booked by the first attempt
+func (c *Client) recordRetry(method string) {
+	c.mu.Lock()
+	c.rollDay()
+	usage := c.ledger.Methods[method]
+	usage.Calls++
+	c.ledger.Methods[method] = usage
+	c.saveLedgerLocked()
+	c.mu.Unlock()
+}
+
+// recordCacheHit counts a call answered from the disk cache
+func (c *Client) recordCacheHit(method string) {
+	c.mu.Lock()
+	c.rollDay()
+	usage := c.ledger.Methods[method]
+	usage.CacheHits++
+	c.ledger.Methods[method] = usage
+	c.mu.Unlock()
+
+	if c.config.OnCacheHit != nil {
+		c.config.OnCacheHit(method)
+	}
+}
+
+// usedLocked returns the units spent today; c.mu must be held
+func (c *Client) usedLocked() int {
+	used := 0
+	for _, m := range c.ledger.Methods {
+		used += m.Units
+	}
+	return used
+}
+
+// rollDay starts a fresh ledger when the quota day changed; c.mu must be held
+func (c *Client) rollDay() {
+	if day := quotaDay(time.Now()); day != c.ledger.Day {
+		c.ledger = quotaLedger{Day: day, Methods: make(map[string]MethodUsage)}
+	}
+}
+
+// ledgerPath returns where today's usage is persisted
+func (c *Client) ledgerPath() string {
+	return filepath.Join(c.config.CacheDir, "youtube-quota.json")
+}
+
+// loadLedger restores today's usage from disk, ignoring ledgers from earlier days
+func (c *Client) loadLedger() {
+	data, err := os.ReadFile(c.ledgerPath())
+	if err != nil {
+		return
+	}
+
+	var ledger quotaLedger
+	if err := json.Unmarshal(data, &ledger); err != nil || ledger.Day != c.ledger.Day {
+		return
+	}
+	if ledger.Methods == nil {
+		ledger.Methods = make(map[string]MethodUsage)
+	}
+	c.ledger = ledger
+}
+
+// saveLedgerLocked persists today's usage; c.mu must be held
+func (c *Client) saveLedgerLocked() {
+	if c.config.CacheDir == "" {
+		return
+	}
+	data, err := json.Marshal(c.ledger)
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(c.config.CacheDir, 0755); err != nil {
+		return
+	}
+	os.WriteFile(c.ledgerPath(), data, 0644)
+}
+
+// IsQuotaError reports whether the API rejected the request because the daily quota is used up
+func IsQuotaError(err error) bool {
+	if errors.Is(err, ErrQuotaExhausted) {
+		return true
+	}
+
+	var apiErr *googleapi.Error
+	if !errors.As(err, &apiErr) || apiErr.Code != 403 {
+		return false
+	}
+	for _, item := range apiErr.Errors {
+		if item.Reason == "quotaExceeded" || item.Reason == "dailyLimitExceeded" {
+			return true
+		}
+	}
+	return false
+}
+
+// isTransient reports whether a failed call is worth retrying: server errors, rate limiting and network trouble
+func isTransient(err error) bool {
+	var apiErr *googleapi.Error
+	if errors.As(err, &apiErr) {
+		return apiErr.Code == 429 || apiErr.Code >= 500
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	return errors.Is(err, context.DeadlineExceeded)
+}
+
+// pacificTime is where YouTube resets daily quotas
+func pacificTime() *time.Location {
+	location, err := time.LoadLocation("America/Los_Angeles")
+	if err != nil {
+		// No tzdata available, PST is close enough
+		return time.FixedZone("PST", -8*60*60)
+	}
+	return location
+}
+
+// quotaDay returns the Pacific-time date that quota spent at t counts against
+func quotaDay(t time.Time) string {
+	return t.In(pacificTime()).Format("2006-01-02")
+}
+
+// NextQuotaReset returns the next midnight in Pacific time, when YouTube resets daily quotas
+func NextQuotaReset(now time.Time) time.Time {
+	location := pacificTime()
+	local := now.In(location)
+	return time.Date(local.Year(), local.Month(), local.Day()+1, 0, 0, 0, 0, location)
+}
@@ -0,0 +1,80 @@
+package ytapi
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"google.golang.org/api/googleapi"
+)
+
+func TestUsageRollsOverToANewDay(t *testing.T) {
+	c := NewClient(nil, Config{DailyQuota: 1000})
+	c.ledger = quotaLedger{
+		Day:       "2000-01-01",
+		Exhausted: true,
+		Methods:   map[string]MethodUsage{MethodSearch: {Calls: 10, Units: 1000}},
+	}
+
+	usage := c.Usage()
+	if usage.Day != quotaDay(time.Now()) {
+		t.Errorf("Day = %s, want %s", usage.Day, quotaDay(time.Now()))
+	}
+	if usage.Used != 0 || usage.Exhausted || len(usage.Methods) != 0 {
+		t.Errorf("usage after rollover = %+v, want a fresh day", usage)
+	}
+	if !c.reserve(MethodSearch) {
+		t.Error("reserve refused a call on a fresh day")
+	}
+}
+
+func TestLoadLedgerIgnoresEarlierDays(t *testing.T) {
+	dir := t.TempDir()
+	write := func(ledger quotaLedger) {
+		data, err := json.Marshal(ledger)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "youtube-quota.json"), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	spent := map[string]MethodUsage{MethodSearch: {Calls: 3, Units: 300}}
+
+	write(quotaLedger{Day: "2000-01-01", Methods: spent})
+	if used := NewClient(nil, Config{CacheDir: dir}).Usage().Used; used != 0 {
+		t.Errorf("Used = %d from yesterday's ledger, want 0", used)
+	}
+
+	write(quotaLedger{Day: quotaDay(time.Now()), Methods: spent})
+	if used := NewClient(nil, Config{CacheDir: dir}).Usage().Used; used != 300 {
+		t.Errorf("Used = %d from today's ledger, want 300", used)
+	}
+}
+
+func TestRetriesBookTheCostOnce(t *testing.T) {
+	c := NewClient(nil, Config{DailyQuota: 1000, RetryAttempts: 3, RetryDelay: time.Millisecond})
+
+	attempts := 0
+	err := c.do(context.Background(), MethodSearch, func(context.Context) error {
+		attempts++
+		return &googleapi.Error{Code: 503}
+	})
+	if err == nil {
+		t.Fatal("do succeeded, want the last error")
+	}
+	if attempts != 3 {
+		t.Errorf("attempts = %d, want 3", attempts)
+	}
+
+	usage := c.Usage()
+	if usage.Used != methodCosts[MethodSearch] {
+		t.Errorf("Used = %d, want %d", usage.Used, methodCosts[MethodSearch])
+	}
+	if calls := usage.Methods[MethodSearch].Calls; calls != 3 {
+		t.Errorf("Calls = %d, want 3", calls)
+	}
+}
@@ -0,0 +1,288 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// YouTubeLink holds the parts of a YouTube URL that matter for queueing
+type YouTubeLink struct {
+	VideoID    string        // 11 character video ID, empty for pure playlist links
+	PlaylistID string        // Value of the list= parameter, if any
+	StartTime  time.Duration // Offset from t= / start=, zero when not set
+}
+
+// videoIDPattern matches a valid YouTube video ID
+var videoIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{11}$`)
+
+// youtubeHosts lists the hostnames that serve regular YouTube pages
+var youtubeHosts = map[string]bool{
+	"youtube.com":              true,
+	"www.youtube.com":          true,
+	"m.youtube.com":            true,
+	"music.youtube.com":        true,
+	"youtube-nocookie.com":     true,
+	"www.youtube-nocookie.com": true,
+}
+
+// ParseYouTubeURL extracts the video ID, playlist ID and start offset from a YouTube URL.
+// Supported forms include watch?v=, youtu.be/, /shorts/, /embed/, /live/, /v/ and
+// /playlist?list= on the www, m and music hosts.
+func ParseYouTubeURL(raw string) (*YouTubeLink, error) {
+	raw = strings.Trim(strings.TrimSpace(raw), "<>")
+	if raw == "" {
+		return nil, fmt.Errorf("empty url")
+	}
+	if !strings.Contains(raw, "://") {
+		raw = "https://" + raw
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid url: %w", err)
+	}
+
+	host := strings.ToLower(u.Hostname())
+	query := u.Query()
+	link := &YouTubeLink{}
+
+	switch {
+	case host == "youtu.be" || host == "www.youtu.be":
+		link.VideoID = firstPathSegment(u.Path)
+	case youtubeHosts[host]:
+		segments := strings.Split(strings.Trim(u.Path, "/"), "/")
+		switch segments[0] {
+		case "watch":
+			link.VideoID = query.Get("v")
+		case "shorts", "embed", "live", "v", "e":
+			if len(segments) > 1 {
+				link.VideoID = segments[1]
+			}
+		case "playlist", "":
+			// Playlist pages and bare hosts only carry list=
+		default:
+			return nil, fmt.Errorf("unsupported youtube path: %s", u.Path)
+		}
+	default:
+		return nil, fmt.Errorf("not a youtube url: %s", host)
+	}
+
+	link.PlaylistID = query.Get("list")
+
+	if link.VideoID != "" && !videoIDPattern.MatchString(link.VideoID) {
+		return nil, fmt.Errorf("invalid video id: %q", link.VideoID)
+	}
+	if link.VideoID == "" && link.PlaylistID == "" {
+		return nil, fmt.Errorf("no video or playlist id in url")
+	}
+
+	// Timestamps can be in the query (t=, start=) or in the fragment (#t=)
+	timestamp := query.Get("t")
+	if timestamp == "" {
+		timestamp = query.Get("start")
+	}
+	if timestamp == "" && strings.HasPrefix(u.Fragment, "t=") {
+		timestamp = strings.TrimPrefix(u.Fragment, "t=")
+	}
+	if timestamp != "" {
+		start, err := parseTimestamp(timestamp)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timestamp %q: %w", timestamp, err)
+		}
+		link.StartTime = start
+	}
+
+	return link, nil
+}
+
+// IsYouTubeURL reports whether the input parses as a YouTube video or playlist link
+func IsYouTubeURL(raw string) bool {
+	_, err := ParseYouTubeURL(raw)
+	return err == nil
+}
+
+// IsPlaylist reports whether the link should be queued as a playlist
+func (l *YouTubeLink) IsPlaylist() bool {
+	return l.PlaylistID != ""
+}
+
+// IsMix reports whether the list is an auto-generated Mix / radio (list=RD...)
+func (l *YouTubeLink) IsMix() bool {
+	return strings.HasPrefix(l.PlaylistID, "RD")
+}
+
+// IsAlbum reports whether the list is an auto-generated album (list=OLAK5uy_...)
+func (l *YouTubeLink) IsAlbum() bool {
+	return strings.HasPrefix(l.PlaylistID, "OLAK5uy_")
+}
+
+// IsPrivateList reports whether the list only exists in the owner's feed
+// (Watch Later, Liked videos) and can't be fetched by the bot
+func (l *YouTubeLink) IsPrivateList() bool {
+	return l.PlaylistID == "WL" || l.PlaylistID == "LL" || l.PlaylistID == "LM"
+}
+
+// ListKind returns a user-facing name for the type of list
+func (l *YouTubeLink) ListKind() string {
+	switch {
+	case l.IsMix():
+		return "Mix"
+	case l.IsAlbum():
+		return "album"
+	default:
+		return "playlist"
+	}
+}
+
+// ListingURL returns the URL yt-dlp should list for the link's playlist.
+// Mixes can't be opened as a playlist page, they need a watch URL with a seed video.
+func (l *YouTubeLink) ListingURL() string {
+	if !l.IsMix() {
+		return l.PlaylistURL()
+	}
+
+	seed := l.VideoID
+	if seed == "" && videoIDPattern.MatchString(strings.TrimPrefix(l.PlaylistID, "RD")) {
+		// Video mixes are named RD<video id>, so the seed can be recovered from the list ID
+		seed = strings.TrimPrefix(l.PlaylistID, "RD")
+	}
+	if seed == "" {
+		return l.PlaylistURL()
+	}
+	return "https://www.youtube.com/watch?v=" + seed + "&list=" + l.PlaylistID
+}
+
+// WatchURL returns the canonical watch URL for the video, without a timestamp
+func (l *YouTubeLink) WatchURL() string {
+	if l.VideoID == "" {
+		return ""
+	}
+	return "https://www.youtube.com/watch?v=" + l.VideoID
+}
+
+// PlaylistURL returns the canonical playlist URL
+func (l *YouTubeLink) PlaylistURL() string {
+	if l.PlaylistID == "" {
+		return ""
+	}
+	return "https://www.youtube.com/playlist?list=" + l.PlaylistID
+}
+
+// firstPathSegment returns the first element of a URL path
+func firstPathSegment(path string) string {
+	return strings.Split(strings.Trim(path, "/"), "/")[0]
+}
+
+// parseTimestamp parses the formats YouTube accepts for t=: "90", "90s", "1m30s", "1h2m3s" and "1:30"
+func parseTimestamp(value string) (time.Duration, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if value == "" {
+		return 0, fmt.Errorf("empty timestamp")
+	}
+
+	// Plain seconds
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, fmt.Errorf("negative timestamp")
+		}
+		return time.Duration(seconds) * time.Second, nil
+	}
+
+	// Clock format (mm:ss or hh:mm:ss)
+	if strings.Contains(value, ":") {
+		return parseClockDuration(value)
+	}
+
+	// Unit format (1h2m3s), which time.ParseDuration already understands
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, err
+	}
+	if d < 0 {
+		return 0, fmt.Errorf("negative timestamp")
+	}
+	return d, nil
+}
+
+// parseClockDuration parses "ss", "mm:ss" and "hh:mm:ss" strings
+func parseClockDuration(value string) (time.Duration, error) {
+	parts := strings.Split(value, ":")
+	if len(parts) > 3 {
+		return 0, fmt.Errorf("too many fields in %q", value)
+	}
+
+	var total int
+	for _, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid field %q", part)
+		}
+		total = total*60 + n
+	}
+
+	return time.Duration(total) * time.Second, nil
+}
+
+// formatClock formats a duration the way YouTube shows it: "4:05" or "1:02:03"
+func formatClock(d time.Duration) string {
+	total := int(d.Round(time.Second).Seconds())
+	hours, minutes, seconds := total/3600, total/60%60, total%60
+	if hours > 0 {
+		return fmt.Sprintf("%d:%02d:%02d", hours, minutes, seconds)
+	}
+	return fmt.Sprintf("%d:%02d", minutes, seconds)
+}
+
+// parseISODuration parses the ISO 8601 durations the YouTube Data API returns, e.g. "PT1H2M3S"
+func parseISODuration(value string) (time.Duration, error) {
+	if !strings.HasPrefix(value, "PT") {
+		return 0, fmt.Errorf("unsupported duration %q", value)
+	}
+	// The remainder uses the same unit letters as time.ParseDuration, just upper case
+	d, err := time.ParseDuration(strings.ToLower(strings.TrimPrefix(value, "PT")))
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %q: %w", value, err)
+	}
+	return d, nil
+}
+
+// formatStartTime returns a " from 1m30s" suffix for queue messages, or "" when the song plays from the start
+func formatStartTime(start time.Duration) string {
+	if start <= 0 {
+		return ""
+	}
+	return " from " + formatDuration(start)
+}
+
+// channelPathPattern matches the channel forms YouTube uses: /@handle, /channel/UC..., /c/name and /user/name
+var channelPathPattern = regexp.MustCompile(`^/(@[^/]+|channel/[^/]+|c/[^/]+|user/[^/]+)`)
+
+// ParseYouTubeChannelURL returns the canonical uploads URL (".../videos") for a channel link or @handle
+func ParseYouTubeChannelURL(raw string) (string, error) {
+	raw = strings.Trim(strings.TrimSpace(raw), "<>")
+	if strings.HasPrefix(raw, "@") {
+		raw = "https://www.youtube.com/" + raw
+	}
+	if !strings.Contains(raw, "://") {
+		raw = "https://" + raw
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", fmt.Errorf("invalid url: %w", err)
+	}
+	if !youtubeHosts[strings.ToLower(u.Hostname())] {
+		return "", fmt.Errorf("not a youtube url: %s", u.Hostname())
+	}
+
+	match := channelPathPattern.FindString(u.Path)
+	if match == "" {
+		return "", fmt.Errorf("not a channel url: %s", u.Path)
+	}
+
+	return "https://www.youtube.com" + match + "/videos", nil
+}
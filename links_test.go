@@ -0,0 +1,134 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseYouTubeURL(t *testing.T) {
+	tests := []struct {
+		name     string
+		raw      string
+		video    string
+		playlist string
+		start    time.Duration
+	}{
+		{"watch", "https://www.youtube.com/watch?v=dQw4w9WgXcQ", "dQw4w9WgXcQ", "", 0},
+		{"no scheme", "youtube.com/watch?v=dQw4w9WgXcQ", "dQw4w9WgXcQ", "", 0},
+		{"angle brackets", "<https://www.youtube.com/watch?v=dQw4w9WgXcQ>", "dQw4w9WgXcQ", "", 0},
+		{"youtu.be", "https://youtu.be/dQw4w9WgXcQ", "dQw4w9WgXcQ", "", 0},
+		{"mobile host", "https://m.youtube.com/watch?v=dQw4w9WgXcQ", "dQw4w9WgXcQ", "", 0},
+		{"music host", "https://music.youtube.com/watch?v=dQw4w9WgXcQ", "dQw4w9WgXcQ", "", 0},
+		{"shorts", "https://www.youtube.com/shorts/dQw4w9WgXcQ", "dQw4w9WgXcQ", "", 0},
+		{"embed", "https://www.youtube.com/embed/dQw4w9WgXcQ", "dQw4w9WgXcQ", "", 0},
+		{"live", "https://www.youtube.com/live/dQw4w9WgXcQ", "dQw4w9WgXcQ", "", 0},
+		{"playlist page", "https://www.youtube.com/playlist?list=PL590L5WQmH8fJ54F369BLDSqIwcs-TCfs", "", "PL590L5WQmH8fJ54F369BLDSqIwcs-TCfs", 0},
+		{"watch with list", "https://www.youtube.com/watch?v=dQw4w9WgXcQ&list=PL590L5WQmH8fJ54F369BLDSqIwcs-TCfs", "dQw4w9WgXcQ", "PL590L5WQmH8fJ54F369BLDSqIwcs-TCfs", 0},
+		{"mix", "https://www.youtube.com/watch?v=dQw4w9WgXcQ&list=RDdQw4w9WgXcQ", "dQw4w9WgXcQ", "RDdQw4w9WgXcQ", 0},
+		{"album", "https://music.youtube.com/playlist?list=OLAK5uy_kZZxVQ0gcS6Cqx4mTLo8h9x2nF7ZJwKBY", "", "OLAK5uy_kZZxVQ0gcS6Cqx4mTLo8h9x2nF7ZJwKBY", 0},
+		{"watch later", "https://www.youtube.com/playlist?list=WL", "", "WL", 0},
+		{"liked videos", "https://www.youtube.com/playlist?list=LL", "", "LL", 0},
+		{"t seconds", "https://www.youtube.com/watch?v=dQw4w9WgXcQ&t=90", "dQw4w9WgXcQ", "", 90 * time.Second},
+		{"t units", "https://youtu.be/dQw4w9WgXcQ?t=1m30s", "dQw4w9WgXcQ", "", 90 * time.Second},
+		{"t clock", "https://youtu.be/dQw4w9WgXcQ?t=1:30", "dQw4w9WgXcQ", "", 90 * time.Second},
+		{"start", "https://www.youtube.com/embed/dQw4w9WgXcQ?start=90", "dQw4w9WgXcQ", "", 90 * time.Second},
+		{"fragment", "https://www.youtube.com/watch?v=dQw4w9WgXcQ#t=1m30s", "dQw4w9WgXcQ", "", 90 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			link, err := ParseYouTubeURL(tt.raw)
+			if err != nil {
+				t.Fatalf("ParseYouTubeURL(%q) failed: %v", tt.raw, err)
+			}
+			if link.VideoID != tt.video || link.PlaylistID != tt.playlist || link.StartTime != tt.start {
+				t.Errorf("ParseYouTubeURL(%q) = {%q %q %s}, want {%q %q %s}",
+					tt.raw, link.VideoID, link.PlaylistID, link.StartTime, tt.video, tt.playlist, tt.start)
+			}
+		})
+	}
+}
+
+func TestParseYouTubeURLRejects(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+	}{
+		{"empty", ""},
+		{"short id", "https://www.youtube.com/watch?v=dQw4w9WgXc"},
+		{"long id", "https://youtu.be/dQw4w9WgXcQQ"},
+		{"bad characters", "https://www.youtube.com/shorts/dQw4w9W$XcQ"},
+		{"no id", "https://www.youtube.com/watch"},
+		{"other host", "https://vimeo.com/watch?v=dQw4w9WgXcQ"},
+		{"lookalike host", "https://youtube.com.evil.example/watch?v=dQw4w9WgXcQ"},
+		{"unsupported path", "https://www.youtube.com/feed/subscriptions"},
+		{"bad timestamp", "https://youtu.be/dQw4w9WgXcQ?t=abc"},
+		{"negative timestamp", "https://youtu.be/dQw4w9WgXcQ?t=-5"},
+		{"bad clock", "https://youtu.be/dQw4w9WgXcQ?t=1:xx"},
+		{"too many clock fields", "https://youtu.be/dQw4w9WgXcQ?t=1:2:3:4"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if link, err := ParseYouTubeURL(tt.raw); err == nil {
+				t.Errorf("ParseYouTubeURL(%q) = %+v, want an error", tt.raw, link)
+			}
+		})
+	}
+}
+
+func TestYouTubeLinkListKinds(t *testing.T) {
+	tests := []struct {
+		playlist string
+		mix      bool
+		album    bool
+		private  bool
+	}{
+		{"PL590L5WQmH8fJ54F369BLDSqIwcs-TCfs", false, false, false},
+		{"RDdQw4w9WgXcQ", true, false, false},
+		{"OLAK5uy_kZZxVQ0gcS6Cqx4mTLo8h9x2nF7ZJwKBY", false, true, false},
+		{"WL", false, false, true},
+		{"LL", false, false, true},
+	}
+
+	for _, tt := range tests {
+		link := &YouTubeLink{PlaylistID: tt.playlist}
+		if link.IsMix() != tt.mix || link.IsAlbum() != tt.album || link.IsPrivateList() != tt.private {
+			t.Errorf("%s: mix=%t album=%t private=%t, want %t %t %t", tt.playlist,
+				link.IsMix(), link.IsAlbum(), link.IsPrivateList(), tt.mix, tt.album, tt.private)
+		}
+	}
+}
+
+func TestParseTimestamp(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Duration
+		ok    bool
+	}{
+		{"90", 90 * time.Second, true},
+		{"90s", 90 * time.Second, true},
+		{"1m30s", 90 * time.Second, true},
+		{"1h2m3s", time.Hour + 2*time.Minute + 3*time.Second, true},
+		{"1:30", 90 * time.Second, true},
+		{"1:02:03", time.Hour + 2*time.Minute + 3*time.Second, true},
+		{"", 0, false},
+		{"abc", 0, false},
+		{"-5", 0, false},
+		{"-1m", 0, false},
+		{"1:-30", 0, false},
+		{"1::30", 0, false},
+		{"1:2:3:4", 0, false},
+	}
+
+	for _, tt := range tests {
+		got, err := parseTimestamp(tt.value)
+		if (err == nil) != tt.ok {
+			t.Errorf("parseTimestamp(%q) error = %v, want ok=%t", tt.value, err, tt.ok)
+			continue
+		}
+		if tt.ok && got != tt.want {
+			t.Errorf("parseTimestamp(%q) = %s, want %s", tt.value, got, tt.want)
+		}
+	}
+}
@@ -12,6 +12,7 @@ import (
 
 	"automuse/config"
 	"automuse/internal/services/audio"
+	"automuse/internal/services/ytapi"
 	"automuse/pkg/dependency"
 	"automuse/pkg/logger"
 	"automuse/pkg/metrics"
@@ -28,6 +29,7 @@ type Application struct {
 	metrics      *metrics.Metrics
 	discord      *discordgo.Session
 	youtube      *youtube.Service
+	youtubeAPI   *ytapi.Client
 	audioManager *audio.Manager
 	ctx          context.Context
 	cancel       context.CancelFunc
@@ -133,12 +135,31 @@ func (app *Application) initialize() error {
 	}
 	app.discord = session
 
-	// Initialize YouTube service
-	youtubeSvc, err := youtube.NewService(app.ctx, option.WithAPIKey(app.config.YouTube.APIKey))
-	if err != nil {
-		return fmt.Errorf("failed to create YouTube service: %w", err)
+	// Initialize YouTube service (optional - without an API key, search uses yt-dlp only)
+	if app.config.YouTube.APIKey != "" {
+		youtubeSvc, err := youtube.NewService(app.ctx, option.WithAPIKey(app.config.YouTube.APIKey))
+		if err != nil {
+			return fmt.Errorf("failed to create YouTube service: %w", err)
+		}
+		app.youtube = youtubeSvc
+
+		// Wrap the service with quota tracking, response caching and retries
+		apiConfig := ytapi.Config{
+			DailyQuota:     app.config.YouTube.DailyQuota,
+			RetryAttempts:  app.config.YouTube.RetryAttempts,
+			RetryDelay:     app.config.YouTube.RetryDelay,
+			RequestTimeout: app.config.YouTube.RequestTimeout,
+			CacheDir:       app.config.Cache.CacheDirectory,
+			CacheTTL:       app.config.YouTube.CacheTTL,
+		}
+		if app.metrics != nil {
+			apiConfig.OnRequest = app.metrics.RecordYouTubeQuota
+			apiConfig.OnCacheHit = app.metrics.RecordYouTubeCacheHit
+		}
+		app.youtubeAPI = ytapi.NewClient(youtubeSvc, apiConfig)
+	} else {
+		app.logger.Info("No YouTube API key configured, searching with yt-dlp only")
 	}
-	app.youtube = youtubeSvc
 
 	// Initialize audio manager
 	audioConfig := audio.Config{
@@ -170,8 +191,8 @@ func (app *Application) initializeLegacyGlobals() {
 	// Set global Discord session reference
 	s = app.discord
 	
-	// Set global YouTube service reference  
-	service = app.youtube
+	// Set global YouTube API client reference (nil without an API key)
+	youtubeAPI = app.youtubeAPI
 	
 	// Initialize global context
 	ctx = app.ctx
@@ -203,21 +224,45 @@ func (app *Application) initializeLegacyGlobals() {
 		historyManager = NewHistoryManager(historyConfig)
 	}
 	
+	// Initialize per-guild settings
+	if guildSettings == nil {
+		guildSettings = NewGuildSettingsStore(app.config.Cache.CacheDirectory+"/guild-settings.json", GuildSettings{
+			FairQueue:              app.config.Queue.FairQueue,
+			MaxTracksPerUser:       app.config.Queue.MaxTracksPerUser,
+			MaxQueuedMinutes:       app.config.Queue.MaxQueuedMinutes,
+			MaxTrackMinutes:        app.config.Queue.MaxTrackMinutes,
+			DuplicatePolicy:        app.config.DefaultDuplicatePolicy(),
+			DuplicateWindowMinutes: app.config.Queue.DuplicateWindowMinutes,
+		})
+	}
+	
 	// Initialize voice instance
 	if v == nil {
 		v = new(VoiceInstance)
 	}
 	v.stop = true
+
+	// Apply queue limits from config
+	maxPlaylistSize = app.config.Queue.MaxPlaylistSize
+
+	// Seed the shuffle RNG, the seed is logged so a shuffle can be reproduced
+	shuffler = newQueueShuffler(app.config.Queue.ShuffleSeed, app.config.Queue.ShuffleAlgorithm)
+	log.Printf("INFO: Shuffle uses %s with seed %d", shuffler.algorithm, shuffler.seed)
+
+	// Search falls back to yt-dlp when the API fails, is out of quota or has no key
+	searchFallbackEnabled = app.config.HasSearchFallback()
+
+	// How long to stay in voice with nothing to play or nobody listening
+	idleTimeout = app.config.Audio.IdleTimeout
+	emptyChannelTimeout = app.config.Audio.EmptyChannelTimeout
+
+	// Dropped voice connections are rejoined and the song resumed
+	autoReconnectVoice = app.config.Features.EnableAutoReconnect
+	voiceReconnectAttempts = app.config.Discord.ReconnectAttempts
+	voiceReconnectDelay = app.config.Discord.ReconnectDelay
 	
-	// Configure DCA options with config values
-	opts.Bitrate = app.config.Audio.Bitrate
-	opts.Volume = app.config.Audio.Volume
-	opts.FrameRate = app.config.Audio.FrameRate
-	opts.FrameDuration = app.config.Audio.FrameDuration
-	opts.CompressionLevel = app.config.Audio.CompressionLevel
-	opts.PacketLoss = app.config.Audio.PacketLoss
-	opts.BufferedFrames = app.config.Audio.BufferedFrames
-	opts.VBR = app.config.Audio.EnableVBR
+	// All playback goes through the audio manager
+	audioManager = app.audioManager
 	
 	app.logger.Info("Legacy global variables initialized for backward compatibility")
 }
@@ -300,11 +345,32 @@ func (app *Application) setupDiscordHandlers() {
 	// Message handler
 	app.discord.AddHandler(app.handleMessage)
 
+	// Component handler (buttons and select menus)
+	app.discord.AddHandler(app.handleInteraction)
+
 	// Voice state update handler
-	app.discord.AddHandler(func(s *discordgo.Session, v *discordgo.VoiceStateUpdate) {
+	app.discord.AddHandler(func(s *discordgo.Session, vs *discordgo.VoiceStateUpdate) {
 		if app.metrics != nil {
 			app.metrics.RecordDiscordEvent("voice_state_update")
 		}
+
+		// Pause when everyone left the bot's channel, resume when someone comes back
+		if vs.GuildID == v.guildID {
+			checkVoiceListeners()
+		}
+	})
+
+	// Gateway resumed after a drop, the voice connection may not have survived it
+	app.discord.AddHandler(func(s *discordgo.Session, r *discordgo.Resumed) {
+		app.logger.Info("Discord connection resumed", logger.Fields{
+			"event": "resumed",
+		})
+
+		if app.metrics != nil {
+			app.metrics.RecordDiscordEvent("resumed")
+		}
+
+		go checkVoiceAfterResume()
 	})
 
 	// Error handler
@@ -392,13 +458,35 @@ func (app *Application) isCommand(content string) bool {
 		   content == "cache" ||
 		   content == "cache-clear" ||
 		   content == "buffer-status" ||
+		   content == "playnext" ||
+		   strings.HasPrefix(content, "playnext ") ||
+		   content == "playnow" ||
+		   strings.HasPrefix(content, "playnow ") ||
 		   content == "shuffle" ||
+		   strings.HasPrefix(content, "shuffle ") ||
+		   content == "unshuffle" ||
 		   content == "emergency-reset" ||
 		   content == "reset" ||
 		   content == "history" ||
+		   strings.HasPrefix(content, "find ") ||
+		   content == "back" ||
+		   content == "previous" ||
+		   strings.HasPrefix(content, "replay ") ||
+		   content == "status" ||
+		   content == "nowplaying" ||
+		   content == "np" ||
+		   content == "loop" ||
+		   content == "settings" ||
+		   strings.HasPrefix(content, "settings ") ||
 		   len(content) > 5 && content[:5] == "play " ||
+		   len(content) > 6 && content[:6] == "play! " ||
 		   len(content) > 5 && content[:5] == "skip " ||
 		   len(content) > 7 && content[:7] == "remove " ||
+		   content == "clear" ||
+		   content == "undo" ||
+		   content == "join" ||
+		   content == "summon" ||
+		   content == "leave" ||
 		   len(content) > 5 && content[:5] == "move "
 }
 
@@ -563,7 +651,7 @@ func (p *PlayKudasaiCommand) Handle(s *discordgo.Session, m *discordgo.MessageCr
 type PlayCommand struct{}
 
 func (p *PlayCommand) CanHandle(content string) bool {
-	return strings.Contains(content, "play") && content != "play help" && content != "play stuff" && content != "play kudasai"
+	return strings.HasPrefix(content, "play") && content != "play help" && content != "play stuff" && content != "play kudasai"
 }
 func (p *PlayCommand) Handle(s *discordgo.Session, m *discordgo.MessageCreate) error {
 	content := strings.TrimSpace(m.Content)
@@ -578,6 +666,32 @@ func (p *PlayCommand) Handle(s *discordgo.Session, m *discordgo.MessageCreate) e
 	return nil
 }
 
+type PlayNextCommand struct{}
+
+func (pn *PlayNextCommand) CanHandle(content string) bool {
+	return content == "playnext" || strings.HasPrefix(content, "playnext ")
+}
+func (pn *PlayNextCommand) Handle(s *discordgo.Session, m *discordgo.MessageCreate) error {
+	go func() {
+		defer RecoverWithErrorHandler(errorHandler, m.ChannelID)
+		playNextCommand(m, placeNext)
+	}()
+	return nil
+}
+
+type PlayNowCommand struct{}
+
+func (pn *PlayNowCommand) CanHandle(content string) bool {
+	return content == "playnow" || strings.HasPrefix(content, "playnow ")
+}
+func (pn *PlayNowCommand) Handle(s *discordgo.Session, m *discordgo.MessageCreate) error {
+	go func() {
+		defer RecoverWithErrorHandler(errorHandler, m.ChannelID)
+		playNextCommand(m, placeNow)
+	}()
+	return nil
+}
+
 type StopCommand struct{}
 
 func (s *StopCommand) CanHandle(content string) bool {
@@ -594,7 +708,7 @@ func (s *StopCommand) Handle(sess *discordgo.Session, m *discordgo.MessageCreate
 type SkipCommand struct{}
 
 func (s *SkipCommand) CanHandle(content string) bool {
-	return strings.Contains(content, "skip")
+	return strings.HasPrefix(content, "skip")
 }
 func (s *SkipCommand) Handle(sess *discordgo.Session, m *discordgo.MessageCreate) error {
 	go func() {
@@ -620,12 +734,40 @@ func (q *QueueCommand) Handle(s *discordgo.Session, m *discordgo.MessageCreate)
 type RemoveCommand struct{}
 
 func (r *RemoveCommand) CanHandle(content string) bool {
-	return strings.Contains(content, "remove")
+	return strings.HasPrefix(content, "remove")
 }
 func (r *RemoveCommand) Handle(s *discordgo.Session, m *discordgo.MessageCreate) error {
+	parts := strings.Fields(m.Content)
+
+	go func() {
+		defer RecoverWithErrorHandler(errorHandler, m.ChannelID)
+		removeCommand(s, m, parts[1:])
+	}()
+	return nil
+}
+
+type ClearQueueCommand struct{}
+
+func (c *ClearQueueCommand) CanHandle(content string) bool {
+	return content == "clear"
+}
+func (c *ClearQueueCommand) Handle(s *discordgo.Session, m *discordgo.MessageCreate) error {
+	go func() {
+		defer RecoverWithErrorHandler(errorHandler, m.ChannelID)
+		clearQueueCommand(s, m)
+	}()
+	return nil
+}
+
+type UndoQueueCommand struct{}
+
+func (u *UndoQueueCommand) CanHandle(content string) bool {
+	return content == "undo"
+}
+func (u *UndoQueueCommand) Handle(s *discordgo.Session, m *discordgo.MessageCreate) error {
 	go func() {
 		defer RecoverWithErrorHandler(errorHandler, m.ChannelID)
-		remove(m)
+		undoQueueCommand(s, m)
 	}()
 	return nil
 }
@@ -669,6 +811,32 @@ func (b *BufferStatusCommand) Handle(s *discordgo.Session, m *discordgo.MessageC
 	return nil
 }
 
+type JoinCommand struct{}
+
+func (j *JoinCommand) CanHandle(content string) bool {
+	return content == "join" || content == "summon" || content == "move here"
+}
+func (j *JoinCommand) Handle(s *discordgo.Session, m *discordgo.MessageCreate) error {
+	go func() {
+		defer RecoverWithErrorHandler(errorHandler, m.ChannelID)
+		joinCommand(s, m)
+	}()
+	return nil
+}
+
+type LeaveCommand struct{}
+
+func (l *LeaveCommand) CanHandle(content string) bool {
+	return content == "leave"
+}
+func (l *LeaveCommand) Handle(s *discordgo.Session, m *discordgo.MessageCreate) error {
+	go func() {
+		defer RecoverWithErrorHandler(errorHandler, m.ChannelID)
+		leaveCommand(s, m)
+	}()
+	return nil
+}
+
 type MoveQueueCommand struct{}
 
 func (mq *MoveQueueCommand) CanHandle(content string) bool {
@@ -690,12 +858,27 @@ func (mq *MoveQueueCommand) Handle(s *discordgo.Session, m *discordgo.MessageCre
 type ShuffleQueueCommand struct{}
 
 func (sq *ShuffleQueueCommand) CanHandle(content string) bool {
-	return content == "shuffle"
+	return content == "shuffle" || strings.HasPrefix(content, "shuffle ")
 }
 func (sq *ShuffleQueueCommand) Handle(s *discordgo.Session, m *discordgo.MessageCreate) error {
+	parts := strings.Fields(m.Content)
+
+	go func() {
+		defer RecoverWithErrorHandler(errorHandler, m.ChannelID)
+		shuffleQueueCommand(s, m, parts[1:])
+	}()
+	return nil
+}
+
+type UnshuffleQueueCommand struct{}
+
+func (uq *UnshuffleQueueCommand) CanHandle(content string) bool {
+	return content == "unshuffle"
+}
+func (uq *UnshuffleQueueCommand) Handle(s *discordgo.Session, m *discordgo.MessageCreate) error {
 	go func() {
 		defer RecoverWithErrorHandler(errorHandler, m.ChannelID)
-		shuffleQueueCommand(s, m, nil)
+		unshuffleQueueCommand(s, m)
 	}()
 	return nil
 }
@@ -752,6 +935,102 @@ func (h *HistoryCommand) Handle(s *discordgo.Session, m *discordgo.MessageCreate
 	return nil
 }
 
+type FindCommand struct{}
+
+func (f *FindCommand) CanHandle(content string) bool {
+	return strings.HasPrefix(content, "find ")
+}
+func (f *FindCommand) Handle(s *discordgo.Session, m *discordgo.MessageCreate) error {
+	parts := strings.Fields(m.Content)
+
+	go func() {
+		defer RecoverWithErrorHandler(errorHandler, m.ChannelID)
+		findCommand(s, m, parts[1:])
+	}()
+	return nil
+}
+
+type BackCommand struct{}
+
+func (b *BackCommand) CanHandle(content string) bool {
+	return content == "back" || content == "previous"
+}
+func (b *BackCommand) Handle(s *discordgo.Session, m *discordgo.MessageCreate) error {
+	go func() {
+		defer RecoverWithErrorHandler(errorHandler, m.ChannelID)
+		backCommand(s, m)
+	}()
+	return nil
+}
+
+type ReplayCommand struct{}
+
+func (r *ReplayCommand) CanHandle(content string) bool {
+	return strings.HasPrefix(content, "replay ")
+}
+func (r *ReplayCommand) Handle(s *discordgo.Session, m *discordgo.MessageCreate) error {
+	parts := strings.Fields(m.Content)
+
+	go func() {
+		defer RecoverWithErrorHandler(errorHandler, m.ChannelID)
+		replayCommand(s, m, parts[1:])
+	}()
+	return nil
+}
+
+type StatusCommand struct{}
+
+func (st *StatusCommand) CanHandle(content string) bool {
+	return content == "status"
+}
+func (st *StatusCommand) Handle(s *discordgo.Session, m *discordgo.MessageCreate) error {
+	go func() {
+		defer RecoverWithErrorHandler(errorHandler, m.ChannelID)
+		statusCommand(s, m)
+	}()
+	return nil
+}
+
+type NowPlayingCommand struct{}
+
+func (np *NowPlayingCommand) CanHandle(content string) bool {
+	return content == "nowplaying" || content == "np"
+}
+func (np *NowPlayingCommand) Handle(s *discordgo.Session, m *discordgo.MessageCreate) error {
+	go func() {
+		defer RecoverWithErrorHandler(errorHandler, m.ChannelID)
+		nowPlayingCommand(s, m)
+	}()
+	return nil
+}
+
+type LoopCommand struct{}
+
+func (l *LoopCommand) CanHandle(content string) bool {
+	return content == "loop"
+}
+func (l *LoopCommand) Handle(s *discordgo.Session, m *discordgo.MessageCreate) error {
+	go func() {
+		defer RecoverWithErrorHandler(errorHandler, m.ChannelID)
+		loopCommand(s, m)
+	}()
+	return nil
+}
+
+type SettingsCommand struct{}
+
+func (st *SettingsCommand) CanHandle(content string) bool {
+	return content == "settings" || strings.HasPrefix(content, "settings ")
+}
+func (st *SettingsCommand) Handle(s *discordgo.Session, m *discordgo.MessageCreate) error {
+	args := strings.Fields(m.Content)[1:]
+	go func() {
+		defer RecoverWithErrorHandler(errorHandler, m.ChannelID)
+		settingsCommand(s, m, args)
+	}()
+	return nil
+}
+
 // checkDependencies checks system dependencies
 func checkDependencies(ctx context.Context, appLogger *logger.Logger) error {
 	appLogger.Info("Checking system dependencies")
@@ -13,17 +13,18 @@ import (
 
 // SongMetadata represents the metadata for a cached song
 type SongMetadata struct {
-	VideoID      string    `json:"video_id"`
-	Title        string    `json:"title"`
-	Duration     string    `json:"duration"`
-	FilePath     string    `json:"file_path"`
-	FileSize     int64     `json:"file_size"`
-	DownloadedAt time.Time `json:"downloaded_at"`
-	LastUsed     time.Time `json:"last_used"`
-	UseCount     int       `json:"use_count"`
-	Artist       string    `json:"artist,omitempty"`
-	Album        string    `json:"album,omitempty"`
-	TitleHash    string    `json:"title_hash"` // For similarity matching
+	VideoID        string        `json:"video_id"`
+	Title          string        `json:"title"`
+	Duration       time.Duration `json:"duration_ns"`        // Zero when unknown
+	LegacyDuration string        `json:"duration,omitempty"` // Free-form duration text from older metadata files, migrated on load
+	FilePath       string        `json:"file_path"`
+	FileSize       int64         `json:"file_size"`
+	DownloadedAt   time.Time     `json:"downloaded_at"`
+	LastUsed       time.Time     `json:"last_used"`
+	UseCount       int           `json:"use_count"`
+	Artist         string        `json:"artist,omitempty"`
+	Album          string        `json:"album,omitempty"`
+	TitleHash      string        `json:"title_hash"` // For similarity matching
 }
 
 // MetadataManager handles song metadata operations
@@ -72,6 +73,20 @@ func (mm *MetadataManager) loadMetadata() error {
 	}
 
 	log.Printf("INFO: Loaded metadata for %d songs", len(mm.metadata))
+
+	// Older files stored durations as text in whatever format the source reported
+	migrated := 0
+	for _, metadata := range mm.metadata {
+		if metadata.LegacyDuration != "" {
+			metadata.Duration = songDuration(metadata.LegacyDuration)
+			metadata.LegacyDuration = ""
+			migrated++
+		}
+	}
+	if migrated > 0 {
+		log.Printf("INFO: Migrated durations of %d cached songs", migrated)
+		return mm.saveMetadataUnsafe()
+	}
 	return nil
 }
 
@@ -104,7 +119,7 @@ func (mm *MetadataManager) saveMetadataUnsafe() error {
 }
 
 // AddSong adds or updates song metadata
-func (mm *MetadataManager) AddSong(videoID, title, duration, filePath string, fileSize int64) error {
+func (mm *MetadataManager) AddSong(videoID, title string, duration time.Duration, filePath string, fileSize int64) error {
 	start := time.Now()
 	defer func() {
 		elapsed := time.Since(start)
@@ -0,0 +1,369 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Component IDs used on the now playing card
+const (
+	nowPlayingComponentPrefix = "np:"
+	nowPlayingPauseID         = nowPlayingComponentPrefix + "pause"
+	nowPlayingSkipID          = nowPlayingComponentPrefix + "skip"
+	nowPlayingStopID          = nowPlayingComponentPrefix + "stop"
+	nowPlayingLoopID          = nowPlayingComponentPrefix + "loop"
+	nowPlayingShuffleID       = nowPlayingComponentPrefix + "shuffle"
+)
+
+const (
+	nowPlayingRefresh  = 5 * time.Second // How often the live card is edited
+	progressBarLength  = 16              // Segments in the text progress bar
+	nowPlayingColor    = 0xFF0000
+	nowPlayingDoneTint = 0x808080
+)
+
+// nowPlayingCard is the embed message that follows the current song
+type nowPlayingCard struct {
+	ChannelID string
+	MessageID string
+	Track     Track // Guarded by mu, the playlist listing can fill in the duration later
+	mu        sync.Mutex
+	done      chan struct{} // Closed to stop the refresh loop
+}
+
+// track returns the song the card shows
+func (c *nowPlayingCard) track() Track {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.Track
+}
+
+// fillDuration sets the song's duration once it is known, if the card shows that video
+func (c *nowPlayingCard) fillDuration(videoID string, duration time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.Track.VideoID == videoID && c.Track.Duration == 0 {
+		c.Track.Duration = duration
+	}
+}
+
+// nowPlayingDuration returns the current song's length, including a duration the playlist
+// listing filled in after the song started
+func nowPlayingDuration() time.Duration {
+	if v.nowPlaying.Duration > 0 {
+		return v.nowPlaying.Duration
+	}
+	if card := liveNowPlayingCard(); card != nil {
+		if track := card.track(); track.VideoID == v.nowPlaying.VideoID {
+			return track.Duration
+		}
+	}
+	return 0
+}
+
+var (
+	currentCard     *nowPlayingCard // Live card for the current song, nil when nothing is playing
+	currentCardLock sync.Mutex
+)
+
+// setPaused pauses or resumes the current song and keeps track of how long it has been paused
+func (v *VoiceInstance) setPaused(paused bool) {
+	if paused == v.paused {
+		return
+	}
+	if paused {
+		v.pausedAt = time.Now()
+	} else if !v.pausedAt.IsZero() {
+		v.pausedTotal += time.Since(v.pausedAt)
+		v.pausedAt = time.Time{}
+	}
+	v.paused = paused
+
+	// The engine holds the audio where it is, so the song resumes exactly where it paused
+	if audioManager != nil {
+		if paused {
+			audioManager.Pause(v.guildID)
+		} else {
+			audioManager.Resume(v.guildID)
+		}
+	}
+}
+
+// resetPlayTime marks the start of a new song
+func (v *VoiceInstance) resetPlayTime() {
+	v.playStartTime = time.Now()
+	v.pausedAt = time.Time{}
+	v.pausedTotal = 0
+}
+
+// playedFor returns how long the current song has been playing, excluding time spent paused
+func (v *VoiceInstance) playedFor() time.Duration {
+	if v.playStartTime.IsZero() {
+		return 0
+	}
+	played := time.Since(v.playStartTime) - v.pausedTotal
+	if !v.pausedAt.IsZero() {
+		played -= time.Since(v.pausedAt)
+	}
+	if played < 0 {
+		played = 0
+	}
+	return played
+}
+
+// elapsed returns how far into the current song playback is, excluding time spent paused
+// and including the start offset of timestamped links
+func (v *VoiceInstance) elapsed() time.Duration {
+	if v.playStartTime.IsZero() {
+		return 0
+	}
+	return v.nowPlaying.StartTime + v.playedFor()
+}
+
+// progressBar renders elapsed/total as a text bar, e.g. "▬▬▬▬🔘───────────"
+func progressBar(elapsed, total time.Duration) string {
+	position := 0
+	if total > 0 {
+		position = int(float64(elapsed) / float64(total) * progressBarLength)
+	}
+	if position < 0 {
+		position = 0
+	} else if position >= progressBarLength {
+		position = progressBarLength - 1
+	}
+	return strings.Repeat("▬", position) + "🔘" + strings.Repeat("─", progressBarLength-position-1)
+}
+
+// renderNowPlaying builds the card for the song; status replaces the progress line once the song is over
+func renderNowPlaying(song Track, status string) *discordgo.MessageEmbed {
+	title := song.Title
+	if song.URL != "" {
+		title = "[" + song.Title + "](" + song.URL + ")"
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Author:      &discordgo.MessageEmbedAuthor{Name: "Now Playing"},
+		Description: "**" + title + "**",
+		Color:       nowPlayingColor,
+	}
+	if song.Thumbnail != "" {
+		embed.Thumbnail = &discordgo.MessageEmbedThumbnail{URL: song.Thumbnail}
+	}
+	if song.RequestedBy != "" {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Requested by", Value: "<@" + song.RequestedBy + ">", Inline: true})
+	}
+	if song.Uploader != "" {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Uploader", Value: song.Uploader, Inline: true})
+	}
+
+	total, hasTotal := song.Duration, song.Duration > 0
+	if status != "" {
+		embed.Author.Name = status
+		embed.Color = nowPlayingDoneTint
+		if hasTotal {
+			embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Length", Value: formatClock(total), Inline: true})
+		}
+		return embed
+	}
+
+	elapsed := v.elapsed()
+	progress := formatClock(elapsed) + " / live"
+	if hasTotal {
+		if elapsed > total {
+			elapsed = total
+		}
+		progress = progressBar(elapsed, total) + "\n`" + formatClock(elapsed) + " / " + formatClock(total) + "`"
+	}
+	if v.paused {
+		progress = "⏸️ Paused\n" + progress
+	}
+	if v.looping {
+		progress = "🔁 Looping\n" + progress
+	}
+	embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Progress", Value: progress})
+
+	queueMutex.Lock()
+	if len(queue) > 0 {
+		embed.Footer = &discordgo.MessageEmbedFooter{Text: fmt.Sprintf("Up next: %s • %d in queue", truncateText(queue[0].Title, 80), len(queue))}
+	}
+	queueMutex.Unlock()
+
+	return embed
+}
+
+// renderNowPlayingControls builds the playback buttons for the live card, reflecting pause and loop state
+func renderNowPlayingControls() []discordgo.MessageComponent {
+	pauseLabel := "Pause"
+	if v.paused {
+		pauseLabel = "Resume"
+	}
+	loopStyle := discordgo.SecondaryButton
+	if v.looping {
+		loopStyle = discordgo.SuccessButton
+	}
+
+	return []discordgo.MessageComponent{
+		discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+			discordgo.Button{Label: pauseLabel, Style: discordgo.PrimaryButton, CustomID: nowPlayingPauseID, Emoji: &discordgo.ComponentEmoji{Name: "⏯️"}},
+			discordgo.Button{Label: "Skip", Style: discordgo.SecondaryButton, CustomID: nowPlayingSkipID, Emoji: &discordgo.ComponentEmoji{Name: "⏭️"}},
+			discordgo.Button{Label: "Stop", Style: discordgo.DangerButton, CustomID: nowPlayingStopID, Emoji: &discordgo.ComponentEmoji{Name: "⏹️"}},
+			discordgo.Button{Label: "Loop", Style: loopStyle, CustomID: nowPlayingLoopID, Emoji: &discordgo.ComponentEmoji{Name: "🔁"}},
+			discordgo.Button{Label: "Shuffle", Style: discordgo.SecondaryButton, CustomID: nowPlayingShuffleID, Emoji: &discordgo.ComponentEmoji{Name: "🔀"}},
+		}},
+	}
+}
+
+// startNowPlayingCard posts the card for a song that just started and keeps it updated until the song ends
+func startNowPlayingCard(channelID string, song Track) {
+	finishNowPlayingCard("")
+
+	msg, err := s.ChannelMessageSendComplex(channelID, &discordgo.MessageSend{
+		Embeds:     []*discordgo.MessageEmbed{renderNowPlaying(song, "")},
+		Components: renderNowPlayingControls(),
+	})
+	if err != nil {
+		log.Printf("WARN: Failed to send now playing card: %v", err)
+		return
+	}
+
+	card := &nowPlayingCard{ChannelID: channelID, MessageID: msg.ID, Track: song, done: make(chan struct{})}
+	currentCardLock.Lock()
+	currentCard = card
+	currentCardLock.Unlock()
+
+	go card.refreshLoop()
+}
+
+// refreshLoop edits the card every few seconds so the progress bar moves
+func (c *nowPlayingCard) refreshLoop() {
+	ticker := time.NewTicker(nowPlayingRefresh)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-ticker.C:
+			c.edit(renderNowPlaying(c.track(), ""), renderNowPlayingControls())
+		}
+	}
+}
+
+// edit replaces the card's embed and buttons; an empty component list removes the buttons
+func (c *nowPlayingCard) edit(embed *discordgo.MessageEmbed, components []discordgo.MessageComponent) {
+	embeds := []*discordgo.MessageEmbed{embed}
+	_, err := s.ChannelMessageEditComplex(&discordgo.MessageEdit{
+		ID:         c.MessageID,
+		Channel:    c.ChannelID,
+		Embeds:     &embeds,
+		Components: &components,
+	})
+	if err != nil {
+		log.Printf("WARN: Failed to update now playing card: %v", err)
+	}
+}
+
+// refreshNowPlayingCard updates the live card right away, e.g. after pause or resume
+func refreshNowPlayingCard() {
+	if card := liveNowPlayingCard(); card != nil {
+		card.edit(renderNowPlaying(card.track(), ""), renderNowPlayingControls())
+	}
+}
+
+// liveNowPlayingCard returns the card for the current song, or nil
+func liveNowPlayingCard() *nowPlayingCard {
+	currentCardLock.Lock()
+	defer currentCardLock.Unlock()
+	return currentCard
+}
+
+// finishNowPlayingCard stops updating the live card and finalises it with the given status
+// ("Finished", "Skipped", ...). An empty status deletes the card instead.
+func finishNowPlayingCard(status string) {
+	currentCardLock.Lock()
+	card := currentCard
+	currentCard = nil
+	currentCardLock.Unlock()
+
+	if card == nil {
+		return
+	}
+	close(card.done)
+
+	if status == "" {
+		if err := s.ChannelMessageDelete(card.ChannelID, card.MessageID); err != nil {
+			log.Printf("WARN: Failed to remove now playing card: %v", err)
+		}
+		return
+	}
+	card.edit(renderNowPlaying(card.track(), status), []discordgo.MessageComponent{})
+}
+
+// nowPlayingCommand reposts the now playing card at the bottom of the channel
+func nowPlayingCommand(s *discordgo.Session, m *discordgo.MessageCreate) {
+	if v.nowPlaying == (Track{}) {
+		s.ChannelMessageSend(m.ChannelID, "**[Muse]** ❌ Nothing is playing right now.")
+		return
+	}
+
+	// The old card is removed so only one live card exists
+	startNowPlayingCard(m.ChannelID, v.nowPlaying)
+}
+
+// handleNowPlayingComponent handles the playback buttons on the now playing card.
+// Each button runs the matching text command, so it gets the same checks as typing it.
+func (app *Application) handleNowPlayingComponent(s *discordgo.Session, i *discordgo.InteractionCreate, data discordgo.MessageComponentInteractionData) {
+	card := liveNowPlayingCard()
+	if card == nil || card.MessageID != i.Message.ID {
+		respondEphemeral(s, i, "**[Muse]** These controls belong to a song that's no longer playing. Use `nowplaying` to get fresh ones.")
+		return
+	}
+
+	var command string
+	switch data.CustomID {
+	case nowPlayingPauseID:
+		command = "pause"
+		if v.paused {
+			command = "resume"
+		}
+	case nowPlayingSkipID:
+		command = "skip"
+	case nowPlayingStopID:
+		command = "stop"
+	case nowPlayingLoopID:
+		command = "loop"
+	case nowPlayingShuffleID:
+		command = "shuffle"
+	default:
+		respondEphemeral(s, i, "**[Muse]** This control is no longer active.")
+		return
+	}
+
+	acknowledgeComponent(s, i)
+	if err := app.processCommand(s, componentMessage(i, command)); err != nil {
+		log.Printf("ERROR: Failed to run %s from now playing controls: %v", command, err)
+	}
+}
+
+// loopCommand turns repeating the current song on or off
+func loopCommand(s *discordgo.Session, m *discordgo.MessageCreate) {
+	if v.nowPlaying == (Track{}) {
+		s.ChannelMessageSend(m.ChannelID, "**[Muse]** ❌ No song is currently playing to loop.")
+		return
+	}
+
+	v.looping = !v.looping
+	refreshNowPlayingCard()
+
+	if v.looping {
+		s.ChannelMessageSend(m.ChannelID, "**[Muse]** 🔁 Looping ["+v.nowPlaying.Title+"]")
+	} else {
+		s.ChannelMessageSend(m.ChannelID, "**[Muse]** 🔁 Loop off, the queue continues after ["+v.nowPlaying.Title+"]")
+	}
+	log.Printf("INFO: Looping %t for song: %s", v.looping, v.nowPlaying.Title)
+}
@@ -326,6 +326,18 @@ func (m *Metrics) RecordYouTubeEvent(event string) {
 	m.IncCounter(fmt.Sprintf("youtube_event_%s", event))
 }
 
+// RecordYouTubeQuota records quota units spent on a YouTube Data API method
+func (m *Metrics) RecordYouTubeQuota(method string, units int, usedToday int) {
+	m.IncCounter(fmt.Sprintf("youtube_api_calls_%s", method))
+	m.AddCounter(fmt.Sprintf("youtube_quota_units_%s", method), int64(units))
+	m.SetGauge("youtube_quota_used_today", float64(usedToday))
+}
+
+// RecordYouTubeCacheHit records a YouTube Data API call answered from the response cache
+func (m *Metrics) RecordYouTubeCacheHit(method string) {
+	m.IncCounter(fmt.Sprintf("youtube_cache_hits_%s", method))
+}
+
 // RecordAudioEvent records audio-related events
 func (m *Metrics) RecordAudioEvent(event string, duration time.Duration) {
 	m.IncCounter(fmt.Sprintf("audio_event_%s", event))
@@ -502,6 +514,14 @@ func RecordYouTubeEvent(event string) {
 	GetGlobalMetrics().RecordYouTubeEvent(event)
 }
 
+func RecordYouTubeQuota(method string, units int, usedToday int) {
+	GetGlobalMetrics().RecordYouTubeQuota(method, units, usedToday)
+}
+
+func RecordYouTubeCacheHit(method string) {
+	GetGlobalMetrics().RecordYouTubeCacheHit(method)
+}
+
 func RecordAudioEvent(event string, duration time.Duration) {
 	GetGlobalMetrics().RecordAudioEvent(event, duration)
 }
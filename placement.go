@@ -0,0 +1,158 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// queuePlacement says where newly requested tracks go in the queue
+type queuePlacement int
+
+const (
+	placeLast queuePlacement = iota // After everything already queued
+	placeNext                       // Right after the current track
+	placeNow                        // Interrupt the current track, which resumes afterwards
+)
+
+// Placements of requests made with playnext / playnow, keyed by the requesting message ID.
+// Everything queued on behalf of that message goes to the requested spot.
+var (
+	requestPlacements      = make(map[string]queuePlacement)
+	requestPlacementsMutex sync.Mutex
+)
+
+// placementFor returns where tracks queued by the given message go
+func placementFor(messageID string) queuePlacement {
+	requestPlacementsMutex.Lock()
+	defer requestPlacementsMutex.Unlock()
+	return requestPlacements[messageID]
+}
+
+func setPlacement(messageID string, placement queuePlacement) {
+	requestPlacementsMutex.Lock()
+	requestPlacements[messageID] = placement
+	requestPlacementsMutex.Unlock()
+}
+
+func clearPlacement(messageID string) {
+	requestPlacementsMutex.Lock()
+	delete(requestPlacements, messageID)
+	requestPlacementsMutex.Unlock()
+}
+
+// placementNote is appended to "Adding [song] to the Queue" messages
+func placementNote(messageID string) string {
+	switch placementFor(messageID) {
+	case placeNext:
+		return ", playing next"
+	case placeNow:
+		return ", playing now"
+	}
+	return ""
+}
+
+// enqueueTracks adds tracks queued for a message at the spot the message asked for
+func enqueueTracks(m *discordgo.MessageCreate, tracks ...Track) {
+	queueMutex.Lock()
+	interrupt := enqueueTracksLocked(m, tracks...)
+	queueMutex.Unlock()
+
+	interruptForPlayNow(interrupt)
+}
+
+// enqueueTracksLocked adds tracks queued for a message at the spot the message asked for; queueMutex
+// must be held. It returns true when playnow re-queued the current track, the caller then has to
+// call interruptForPlayNow once the lock is released.
+func enqueueTracksLocked(m *discordgo.MessageCreate, tracks ...Track) bool {
+	placement := placementFor(m.ID)
+	if placement == placeLast {
+		queue = append(queue, tracks...)
+		return false
+	}
+
+	front := make([]Track, 0, len(tracks)+1)
+	front = append(front, tracks...)
+	interrupt := placement == placeNow && v.nowPlaying != (Track{}) && !v.interrupting
+	if interrupt {
+		// Pick the current track up again where it was cut off
+		front = append(front, v.nowPlaying.resumedAt(v.elapsed(), v.playedFor()))
+		v.interrupting = true
+	}
+	queue = append(front, queue...)
+	return interrupt
+}
+
+// interruptForPlayNow cuts off the current track after enqueueTracksLocked re-queued it for playnow
+func interruptForPlayNow(interrupt bool) {
+	if interrupt {
+		log.Printf("INFO: Interrupting [%s] at %s for a playnow request", v.nowPlaying.Title, formatClock(v.elapsed()))
+		prepSkip()
+	}
+}
+
+// enqueueTrack adds a single track queued for a message
+func enqueueTrack(m *discordgo.MessageCreate, track Track) {
+	enqueueTracks(m, track)
+}
+
+// playNextCommand handles `playnext` and `playnow`: a link or search (the top result is used)
+// is queued through the regular play path, a queue position is moved to the front
+func playNextCommand(m *discordgo.MessageCreate, placement queuePlacement) {
+	name := "playnext"
+	if placement == placeNow {
+		name = "playnow"
+	}
+
+	args := strings.Fields(m.Content)[1:]
+	if len(args) == 0 {
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("**[Muse]** Usage: `%s <url|search terms>` or `%s <queue position>`", name, name))
+		return
+	}
+
+	if settingsFor(m.GuildID).FairQueue {
+		s.ChannelMessageSend(m.ChannelID, "**[Muse]** The fair queue decides the order on this server, songs can't skip ahead. Turn it off with `settings fairqueue off`.")
+		return
+	}
+
+	if position, err := strconv.Atoi(args[0]); err == nil && len(args) == 1 {
+		playQueuedNext(m, position, placement)
+		return
+	}
+
+	// Reuse the play command; searches take the top result like `play!`
+	content := "play " + strings.Join(args, " ")
+	if findYouTubeLink(append([]string{"play"}, args...)) == "" {
+		content = "play! " + strings.Join(args, " ")
+	}
+	request := *m.Message
+	request.Content = content
+
+	setPlacement(m.ID, placement)
+	defer clearPlacement(m.ID)
+	queueSong(&discordgo.MessageCreate{Message: &request})
+}
+
+// playQueuedNext moves a queued track to the front, interrupting the current track for playnow
+func playQueuedNext(m *discordgo.MessageCreate, position int, placement queuePlacement) {
+	queueMutex.Lock()
+	if position < 1 || position > len(queue) {
+		queueMutex.Unlock()
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("**[Muse]** Position must be between 1 and %d", len(queue)))
+		return
+	}
+	track := queue[position-1]
+	queue = append(queue[:position-1], queue[position:]...)
+	queueMutex.Unlock()
+
+	setPlacement(m.ID, placement)
+	defer clearPlacement(m.ID)
+	enqueueTrack(m, track)
+
+	s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("**[Muse]** :arrow_up: Moved [%s] to the front%s", track.Title, placementNote(m.ID)))
+	go refreshNowPlayingCard()
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"path/filepath"
+
+	"automuse/internal/services/audio"
+)
+
+// playbackBoost is the ffmpeg filter every song plays through, Discord plays quieter than most players
+const playbackBoost = "volume=1.5"
+
+// playTrack plays the current song on the bot's voice connection and returns once it ends, is
+// skipped or stopped, or the connection drops. The result's position says where it got to.
+func playTrack() audio.Result {
+	var path string
+	var err error
+	if v.nowPlaying.Source == SourceLocal {
+		path, err = v.audioFile(filepath.Base(v.nowPlaying.FilePath), true)
+	} else {
+		path, err = v.audioFile(v.nowPlaying.playbackPath(), false)
+	}
+	if err != nil {
+		log.Printf("ERROR: No audio to play for [%s]: %v", v.nowPlaying.Title, err)
+		return audio.Result{Err: err}
+	}
+	if v.stop {
+		return audio.Result{Stopped: true} // Skipped while downloading
+	}
+	if v.voice == nil {
+		return audio.Result{Err: fmt.Errorf("not in a voice channel")}
+	}
+	if !v.voice.Ready {
+		return audio.Result{Position: v.nowPlaying.StartTime, Err: audio.ErrVoiceLost}
+	}
+
+	log.Printf("INFO: Playing audio file: %s (start: %s)", path, v.nowPlaying.StartTime)
+	done, err := audioManager.Play(ctx, v.guildID, v.voice, &audio.Track{
+		Title:    v.nowPlaying.Title,
+		URL:      path,
+		Duration: v.nowPlaying.Duration,
+		Start:    v.nowPlaying.StartTime,
+		Filters:  []string{playbackBoost},
+	})
+	if err != nil {
+		log.Printf("ERROR: Failed to start playback of [%s]: %v", v.nowPlaying.Title, err)
+		return audio.Result{Err: err}
+	}
+
+	// Paused or stopped while the file was being fetched
+	if v.paused {
+		audioManager.Pause(v.guildID)
+	}
+	if v.stop {
+		stopPlayback()
+	}
+
+	result := <-done
+	if result.Err != nil {
+		log.Printf("WARN: Playback of [%s] ended at %s: %v", v.nowPlaying.Title, formatClock(result.Position), result.Err)
+	}
+	return result
+}
+
+// stopPlayback cuts off the song that is playing, if any
+func stopPlayback() {
+	if audioManager != nil {
+		audioManager.Stop(v.guildID)
+	}
+}
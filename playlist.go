@@ -0,0 +1,148 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PlaylistOptions holds the selection options that can follow a playlist link,
+// e.g. `play <playlist-url> 50-120 --reverse --max-duration 10m`
+type PlaylistOptions struct {
+	RangeStart  int           // 1-based first entry to take, 0 when no range was given
+	RangeEnd    int           // 1-based last entry to take (inclusive), 0 for "until the end"
+	Reverse     bool          // Queue the selection in reverse order
+	Shuffle     bool          // Queue the selection in random order
+	MaxDuration time.Duration // Skip entries longer than this, 0 disables the filter
+}
+
+// parsePlaylistOptions parses the arguments that follow a playlist link
+func parsePlaylistOptions(args []string) (PlaylistOptions, error) {
+	var opts PlaylistOptions
+
+	for i := 0; i < len(args); i++ {
+		arg := strings.ToLower(args[i])
+
+		switch {
+		case arg == "--reverse":
+			opts.Reverse = true
+		case arg == "--shuffle":
+			opts.Shuffle = true
+		case arg == "--max-duration":
+			if i+1 >= len(args) {
+				return opts, fmt.Errorf("--max-duration needs a value, e.g. `--max-duration 10m`")
+			}
+			i++
+			maxDuration, err := parseTimestamp(args[i])
+			if err != nil || maxDuration <= 0 {
+				return opts, fmt.Errorf("invalid --max-duration value %q, use something like 10m or 4:30", args[i])
+			}
+			opts.MaxDuration = maxDuration
+		case strings.Contains(arg, "-") && !strings.HasPrefix(arg, "-"):
+			start, end, err := parsePlaylistRange(arg)
+			if err != nil {
+				return opts, err
+			}
+			opts.RangeStart, opts.RangeEnd = start, end
+		default:
+			return opts, fmt.Errorf("unknown playlist option %q", args[i])
+		}
+	}
+
+	if opts.Reverse && opts.Shuffle {
+		return opts, fmt.Errorf("--reverse and --shuffle can't be used together")
+	}
+
+	return opts, nil
+}
+
+// parsePlaylistRange parses "50-120" or "50-" into 1-based inclusive bounds (end 0 means no upper bound)
+func parsePlaylistRange(value string) (int, int, error) {
+	parts := strings.SplitN(value, "-", 2)
+
+	start, err := strconv.Atoi(parts[0])
+	if err != nil || start < 1 {
+		return 0, 0, fmt.Errorf("invalid playlist range %q, use something like 50-120", value)
+	}
+
+	end := 0
+	if parts[1] != "" {
+		end, err = strconv.Atoi(parts[1])
+		if err != nil || end < start {
+			return 0, 0, fmt.Errorf("invalid playlist range %q, use something like 50-120", value)
+		}
+	}
+
+	return start, end, nil
+}
+
+// IsSet reports whether any selection option was given
+func (o PlaylistOptions) IsSet() bool {
+	return o.RangeStart > 0 || o.Reverse || o.Shuffle || o.MaxDuration > 0
+}
+
+// Apply returns the entries selected by the options. The range refers to positions in
+// the original playlist and is applied before the duration filter and reordering.
+// Entries without a known duration are kept by the duration filter.
+func (o PlaylistOptions) Apply(entries []playlistEntry) []playlistEntry {
+	selected := entries
+
+	if o.RangeStart > 0 {
+		if o.RangeStart > len(selected) {
+			return []playlistEntry{}
+		}
+		end := len(selected)
+		if o.RangeEnd > 0 && o.RangeEnd < end {
+			end = o.RangeEnd
+		}
+		selected = selected[o.RangeStart-1 : end]
+	}
+
+	// Work on a copy so reordering never touches the caller's slice
+	result := make([]playlistEntry, 0, len(selected))
+	for _, entry := range selected {
+		if o.MaxDuration > 0 && entry.Duration != "" {
+			if d, err := parseClockDuration(entry.Duration); err == nil && d > o.MaxDuration {
+				continue
+			}
+		}
+		result = append(result, entry)
+	}
+
+	if o.Reverse {
+		for i, j := 0, len(result)-1; i < j; i, j = i+1, j-1 {
+			result[i], result[j] = result[j], result[i]
+		}
+	}
+
+	if o.Shuffle {
+		shuffler.Swap(len(result), func(i, j int) {
+			result[i], result[j] = result[j], result[i]
+		})
+	}
+
+	return result
+}
+
+// Describe returns a short human-readable summary of the options, used in chat messages
+func (o PlaylistOptions) Describe() string {
+	var parts []string
+	if o.RangeStart > 0 {
+		if o.RangeEnd > 0 {
+			parts = append(parts, fmt.Sprintf("entries %d-%d", o.RangeStart, o.RangeEnd))
+		} else {
+			parts = append(parts, fmt.Sprintf("entries %d onwards", o.RangeStart))
+		}
+	}
+	if o.MaxDuration > 0 {
+		parts = append(parts, "max "+formatDuration(o.MaxDuration)+" per song")
+	}
+	if o.Reverse {
+		parts = append(parts, "reversed")
+	}
+	if o.Shuffle {
+		parts = append(parts, "shuffled")
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParsePlaylistOptions(t *testing.T) {
+	tests := []struct {
+		args []string
+		want PlaylistOptions
+		ok   bool
+	}{
+		{nil, PlaylistOptions{}, true},
+		{[]string{"50-120"}, PlaylistOptions{RangeStart: 50, RangeEnd: 120}, true},
+		{[]string{"50-"}, PlaylistOptions{RangeStart: 50}, true},
+		{[]string{"5-5"}, PlaylistOptions{RangeStart: 5, RangeEnd: 5}, true},
+		{[]string{"--reverse"}, PlaylistOptions{Reverse: true}, true},
+		{[]string{"--SHUFFLE"}, PlaylistOptions{Shuffle: true}, true},
+		{[]string{"--max-duration", "10m"}, PlaylistOptions{MaxDuration: 10 * time.Minute}, true},
+		{[]string{"--max-duration", "4:30"}, PlaylistOptions{MaxDuration: 4*time.Minute + 30*time.Second}, true},
+		{[]string{"1-10", "--reverse", "--max-duration", "90"}, PlaylistOptions{RangeStart: 1, RangeEnd: 10, Reverse: true, MaxDuration: 90 * time.Second}, true},
+		{[]string{"--reverse", "--shuffle"}, PlaylistOptions{}, false},
+		{[]string{"--max-duration"}, PlaylistOptions{}, false},
+		{[]string{"--max-duration", "0"}, PlaylistOptions{}, false},
+		{[]string{"--max-duration", "soon"}, PlaylistOptions{}, false},
+		{[]string{"0-10"}, PlaylistOptions{}, false},
+		{[]string{"10-5"}, PlaylistOptions{}, false},
+		{[]string{"a-b"}, PlaylistOptions{}, false},
+		{[]string{"-5"}, PlaylistOptions{}, false},
+		{[]string{"--loud"}, PlaylistOptions{}, false},
+		{[]string{"50"}, PlaylistOptions{}, false},
+	}
+
+	for _, tt := range tests {
+		opts, err := parsePlaylistOptions(tt.args)
+		if (err == nil) != tt.ok {
+			t.Errorf("parsePlaylistOptions(%q) error = %v, want ok=%t", tt.args, err, tt.ok)
+			continue
+		}
+		if tt.ok && opts != tt.want {
+			t.Errorf("parsePlaylistOptions(%q) = %+v, want %+v", tt.args, opts, tt.want)
+		}
+	}
+}
+
+// entryIDs joins the IDs of playlist entries, e.g. "abc"
+func entryIDs(entries []playlistEntry) string {
+	var ids strings.Builder
+	for _, entry := range entries {
+		ids.WriteString(entry.ID)
+	}
+	return ids.String()
+}
+
+func TestPlaylistOptionsApply(t *testing.T) {
+	entries := []playlistEntry{
+		{ID: "a", Duration: "3:00"},
+		{ID: "b", Duration: "12:00"},
+		{ID: "c"},
+		{ID: "d", Duration: "1:02:03"},
+		{ID: "e", Duration: "4:30"},
+	}
+
+	tests := []struct {
+		name string
+		opts PlaylistOptions
+		want string
+	}{
+		{"everything", PlaylistOptions{}, "abcde"},
+		{"range", PlaylistOptions{RangeStart: 2, RangeEnd: 4}, "bcd"},
+		{"open range", PlaylistOptions{RangeStart: 4}, "de"},
+		{"range past the end", PlaylistOptions{RangeStart: 4, RangeEnd: 50}, "de"},
+		{"range after the end", PlaylistOptions{RangeStart: 6}, ""},
+		{"reverse", PlaylistOptions{Reverse: true}, "edcba"},
+		{"max duration keeps unknown", PlaylistOptions{MaxDuration: 5 * time.Minute}, "ace"},
+		{"range before filter", PlaylistOptions{RangeStart: 2, RangeEnd: 3, MaxDuration: 5 * time.Minute}, "c"},
+		{"everything together", PlaylistOptions{RangeStart: 1, RangeEnd: 4, MaxDuration: 10 * time.Minute, Reverse: true}, "ca"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := entryIDs(tt.opts.Apply(entries)); got != tt.want {
+				t.Errorf("Apply(%+v) = %q, want %q", tt.opts, got, tt.want)
+			}
+		})
+	}
+
+	if got := entryIDs(entries); got != "abcde" {
+		t.Errorf("Apply changed the caller's entries to %q", got)
+	}
+
+	shuffled := []byte(entryIDs(PlaylistOptions{Shuffle: true}.Apply(entries)))
+	sort.Slice(shuffled, func(i, j int) bool { return shuffled[i] < shuffled[j] })
+	if string(shuffled) != "abcde" {
+		t.Errorf("Apply with --shuffle returned entries %q, want a reordering of abcde", shuffled)
+	}
+}
@@ -6,7 +6,6 @@ import (
 	"strings"
 
 	"github.com/bwmarrin/discordgo"
-	"github.com/kkdai/youtube/v2"
 )
 
 func sanitizeQueueSongInputs(m *discordgo.MessageCreate) ([]string, bool) {
@@ -14,7 +13,6 @@ func sanitizeQueueSongInputs(m *discordgo.MessageCreate) ([]string, bool) {
 	isValid := false
 	parsedContent := m.Content
 	parsedContent = strings.Split(parsedContent, "&index=")[0]
-	parsedContent = strings.Split(parsedContent, "&t=")[0]
 	msgData := strings.Split(parsedContent, " ")
 
 	// If the message data is not empty, check if the user entered a valid command
@@ -32,6 +30,10 @@ func sanitizeQueueSongInputs(m *discordgo.MessageCreate) ([]string, bool) {
 				if !playWasCalled && value == "play" {
 					tmp = append(tmp, value)
 					playWasCalled = true
+				} else if !playWasCalled && value == "play!" {
+					// play! is shorthand for play --first
+					tmp = append(tmp, "play", "--first")
+					playWasCalled = true
 				} else if playWasCalled && value != "play" {
 					tmp = append(tmp, value)
 				}
@@ -71,9 +73,9 @@ func sanitizeQueueSongInputs(m *discordgo.MessageCreate) ([]string, bool) {
 			if strings.Contains(parsedContent, " -pl ") {
 				if msgData[1] == "-pl" {
 					if strings.Contains(msgData[2], "youtube") {
-						if !strings.Contains(msgData[2], "list=PL") {
+						if link, err := ParseYouTubeURL(msgData[2]); err != nil || !link.IsPlaylist() {
 							playlistPass = false
-							s.ChannelMessageSend(m.ChannelID, "**[Muse]** You must enter a valid playlist, not a list - The ID must begin with PL.")
+							s.ChannelMessageSend(m.ChannelID, "**[Muse]** You must enter a valid playlist, Mix or album link - it needs a list= parameter.")
 						}
 					}
 				} else {
@@ -92,63 +94,109 @@ func sanitizeQueueSongInputs(m *discordgo.MessageCreate) ([]string, bool) {
 	return msgData, isValid
 }
 
-// Prepares the play command if a playlist is found in the url
-func prepPlaylistCommand(commData []string, m *discordgo.MessageCreate) {
-	// Only use lists starting with PL (Playlist only, lists are local to your own feed and cannot be used)
-	if strings.Contains(m.Content, "list=PL") {
-		// The url must be the second or third parameter
-		if len(commData) == 2 {
-			prepPlaylist(commData[1], m)
-		} else if len(commData) == 3 {
-			prepPlaylist(commData[2], m)
-		} else {
-			s.ChannelMessageSend(m.ChannelID, "**[Muse]** The url must be the second or third parameter")
+// Checks if the user is queuing a song or playlist
+func prepWatchCommand(commData []string, m *discordgo.MessageCreate) bool {
+	rawLink := findYouTubeLink(commData)
+	link, err := ParseYouTubeURL(rawLink)
+	if err != nil {
+		log.Printf("WARN: Could not parse YouTube link %q: %v", rawLink, err)
+		s.ChannelMessageSend(m.ChannelID, "**[Muse]** The url must be the second or third parameter")
+		return false
+	}
+
+	// Links with a list= parameter are queued as playlists, even when they also carry v=
+	if link.IsPlaylist() {
+		log.Printf("INFO: Detected %s URL: %s (playlist ID: %s)", link.ListKind(), rawLink, link.PlaylistID)
+
+		// Watch Later and Liked videos only exist in the owner's feed
+		if link.IsPrivateList() {
+			s.ChannelMessageSend(m.ChannelID, "**[Muse]** That list is local to your own feed and cannot be used. Make a public or unlisted playlist instead :unamused:")
+			return true
 		}
-	} else {
-		s.ChannelMessageSend(m.ChannelID, "**[Muse]** Lists are not accepted, only playlists are. A valid link id contains PL :unamused:")
+
+		// Anything after the link selects which part of the playlist to queue
+		opts, err := parsePlaylistOptions(playlistOptionArgs(commData, rawLink))
+		if err != nil {
+			s.ChannelMessageSend(m.ChannelID, "**[Muse]** "+err.Error())
+			return true // Nothing was queued, but don't fall through to the generic error message
+		}
+
+		s.ChannelMessageSend(m.ChannelID, "**[Muse]** Detected "+link.ListKind()+"! Starting first song and queuing rest in background... :infinity:")
+
+		// Start the threaded playlist processing
+		queuePlaylistThreaded(playlistSourceFromLink(link), opts, m)
+		return true // Indicate that playback was already started
 	}
+
+	// Regular single video (without playlist)
+	queueSingleSong(m, rawLink)
+	return false // Regular queueing, no playback started yet
 }
 
-// Checks if the user is queuing a song or playlist
-func prepWatchCommand(commData []string, m *discordgo.MessageCreate) bool {
-	// Check if this is a playlist URL (contains list= or /playlist?)
-	if strings.Contains(commData[1], "list=") || strings.Contains(commData[1], "/playlist?") {
-		log.Printf("INFO: Detected playlist URL: %s", commData[1])
-
-		var playlistID string
-		
-		// Extract playlist ID from different URL formats
-		if strings.Contains(commData[1], "/playlist?list=") {
-			// Format: https://www.youtube.com/playlist?list=PL...
-			parts := strings.SplitN(commData[1], "list=", 2)
-			if len(parts) > 1 {
-				playlistID = strings.Split(parts[1], "&")[0]
-			}
-		} else if strings.Contains(commData[1], "list=") {
-			// Format: https://www.youtube.com/watch?v=...&list=PL...
-			parts := strings.SplitN(commData[1], "list=", 2)
-			if len(parts) > 1 {
-				playlistID = strings.Split(parts[1], "&")[0]
+// Queues the latest uploads of a channel: `play channel <url|@handle> [count] [playlist options]`
+func prepChannelCommand(commData []string, m *discordgo.MessageCreate) {
+	if len(commData) < 3 {
+		s.ChannelMessageSend(m.ChannelID, "**[Muse]** Usage: `play channel <channel-url or @handle> [number of uploads]`")
+		return
+	}
+
+	channelURL, err := ParseYouTubeChannelURL(commData[2])
+	if err != nil {
+		log.Printf("WARN: Could not parse channel link %q: %v", commData[2], err)
+		s.ChannelMessageSend(m.ChannelID, "**[Muse]** That doesn't look like a YouTube channel. Use a link like <https://www.youtube.com/@name> or just @name")
+		return
+	}
+
+	// An optional plain number picks how many of the latest uploads to queue
+	count := defaultChannelUploads
+	optionArgs := commData[3:]
+	if len(optionArgs) > 0 {
+		if n, err := strconv.Atoi(optionArgs[0]); err == nil {
+			if n <= 0 {
+				s.ChannelMessageSend(m.ChannelID, "**[Muse]** The number of uploads must be greater than 0")
+				return
 			}
+			count = n
+			optionArgs = optionArgs[1:]
 		}
+	}
+	if count > maxPlaylistSize {
+		s.ChannelMessageSend(m.ChannelID, "**[Muse]** Only the latest "+strconv.Itoa(maxPlaylistSize)+" uploads can be queued at once")
+		count = maxPlaylistSize
+	}
 
-		if playlistID != "" {
-			log.Printf("INFO: Extracted playlist ID: %s", playlistID)
-			s.ChannelMessageSend(m.ChannelID, "**[Muse]** Detected playlist! Starting first song and queuing rest in background... :infinity:")
+	opts, err := parsePlaylistOptions(optionArgs)
+	if err != nil {
+		s.ChannelMessageSend(m.ChannelID, "**[Muse]** "+err.Error())
+		return
+	}
 
-			// Start the threaded playlist processing
-			queuePlaylistThreaded(playlistID, m)
-			return true // Indicate that playback was already started
-		} else {
-			s.ChannelMessageSend(m.ChannelID, "**[Muse]** Could not extract playlist ID from URL")
+	log.Printf("INFO: Queueing latest %d uploads from channel %s", count, channelURL)
+	queuePlaylistThreaded(playlistSource{URL: channelURL, Kind: "channel", Limit: count}, opts, m)
+}
+
+// playlistOptionArgs returns the command arguments other than the link and the -pl flag
+func playlistOptionArgs(commData []string, rawLink string) []string {
+	var args []string
+	for _, arg := range commData[1:] {
+		if arg != rawLink && arg != "-pl" {
+			args = append(args, arg)
 		}
-	} else if strings.Contains(commData[1], "watch?") {
-		// Regular single video (without playlist)
-		queueSingleSong(m, commData[1])
-	} else {
-		s.ChannelMessageSend(m.ChannelID, "**[Muse]** The url must be the second or third parameter")
 	}
-	return false // Regular queueing, no playback started yet
+	return args
+}
+
+// findYouTubeLink returns the first command argument that is a YouTube link, or "" if there is none
+func findYouTubeLink(commData []string) string {
+	if len(commData) < 2 {
+		return ""
+	}
+	for _, arg := range commData[1:] {
+		if IsYouTubeURL(arg) {
+			return arg
+		}
+	}
+	return ""
 }
 
 // Prepares the play command when a song is manually entered
@@ -164,10 +212,6 @@ func prepFirstSongEntered(m *discordgo.MessageCreate, isManual bool) {
 	setPlaybackState(true)
 	defer setPlaybackState(false)
 
-	if len(queue) > 0 {
-		s.ChannelMessageSend(m.ChannelID, "**[Muse]** Playing ["+queue[0].Title+"] :notes:")
-	}
-
 	if isManual {
 		playQueue(m, true)
 	} else {
@@ -178,51 +222,16 @@ func prepFirstSongEntered(m *discordgo.MessageCreate, isManual bool) {
 // Prepares the play command when a numerical option is chosen (queue or search)
 func prepSearchQueueSelector(commData []string, m *discordgo.MessageCreate) {
 	if len(commData) >= 2 {
-		if input, err := strconv.Atoi(commData[1]); err == nil && searchRequested {
+		// Numbers pick from this user's own search results if they have any, otherwise from the queue
+		searchActive := hasActiveSearch(m.GuildID, m.Author.ID)
+		if input, err := strconv.Atoi(commData[1]); err == nil && searchActive {
 			playFromSearch(input, m)
-		} else if input, err := strconv.Atoi(commData[1]); err == nil && !searchRequested {
+		} else if input, err := strconv.Atoi(commData[1]); err == nil && !searchActive {
 			playFromQueue(input, m)
 		} else {
-			getSearch(m)
-		}
-	}
-}
-
-// Prepares the songs to be queued from the playlist
-func prepPlaylist(message string, m *discordgo.MessageCreate) {
-	// Check if the message contains a playlist by checking the list= parameter
-	if strings.Contains(message, "list=") {
-		println(m.Content)
-		playlistID := strings.SplitN(message, "list=", 2)[1]
-		s.ChannelMessageSend(m.ChannelID, "**[Muse]** Queueing Your PlayList... :infinity:")
-		queuePlaylist(playlistID, m)
-	}
-}
-
-// Prepares the song's format for playback (quality)
-func prepSongFormat(format youtube.FormatList) *youtube.Format {
-	// First try to find formats without cipher requirements
-	for _, f := range format {
-		// Check if the format has a direct URL (no cipher)
-		if f.URL != "" && f.Cipher == "" {
-			log.Printf("[DEBUG] Found format without cipher: Itag=%d, Quality=%s, MimeType=%s",
-				f.ItagNo, f.Quality, f.MimeType)
-			return &f
-		}
-	}
-
-	// If no direct URL formats found, try audio-only formats
-	for _, f := range format {
-		if f.ItagNo == 140 || f.ItagNo == 251 || f.ItagNo == 250 || f.ItagNo == 249 {
-			log.Printf("[DEBUG] Selected audio-only format: Itag=%d, Quality=%s, MimeType=%s",
-				f.ItagNo, f.Quality, f.MimeType)
-			return &f
+			getSearch(commData, m)
 		}
 	}
-
-	// If no suitable format found, fall back to the first format
-	log.Printf("[DEBUG] No suitable format found, falling back to first format")
-	return &format[0]
 }
 
 // Preps the skip command
@@ -233,23 +242,7 @@ func prepSkip() {
 	v.paused = false // Reset pause state when skipping
 
 	// Force stop the current audio stream
-	if v.voice != nil {
-		v.voice.Speaking(false)
-		log.Printf("INFO: Set speaking to false for skip")
-	}
-
-	// Cleanup encoder if it exists
-	if v.encoder != nil {
-		log.Printf("INFO: Cleaning up encoder for skip")
-		v.encoder.Cleanup()
-		v.encoder = nil
-	}
-
-	// Cleanup stream if it exists
-	if v.stream != nil {
-		log.Printf("INFO: Cleaning up stream for skip")
-		v.stream = nil
-	}
+	stopPlayback()
 
 	log.Printf("INFO: Skip preparation completed")
 }
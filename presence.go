@@ -0,0 +1,221 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// Timers for leaving voice when nothing is playing or nobody is listening, guarded by presenceMutex
+var (
+	presenceMutex  sync.Mutex
+	idleTimer      *time.Timer // Runs while the bot sits in voice with nothing to play
+	emptyTimer     *time.Timer // Runs while nobody is in the bot's voice channel
+	pausedForEmpty bool        // The current song was paused because everyone left
+	idleChannelID  string      // Text channel for the idle notices
+)
+
+// voiceListeners counts the people, not bots, in the bot's voice channel
+func voiceListeners() int {
+	if v.voice == nil || s == nil || s.State == nil {
+		return 0
+	}
+	guild, err := s.State.Guild(v.guildID)
+	if err != nil {
+		return 0
+	}
+
+	botID := ""
+	if s.State.User != nil {
+		botID = s.State.User.ID
+	}
+
+	// Collect first, looking members up takes the state lock again
+	var userIDs []string
+	s.State.RLock()
+	for _, vs := range guild.VoiceStates {
+		if vs == nil || vs.ChannelID != v.voice.ChannelID || vs.UserID == botID {
+			continue
+		}
+		if vs.Member != nil && vs.Member.User != nil && vs.Member.User.Bot {
+			continue
+		}
+		userIDs = append(userIDs, vs.UserID)
+	}
+	s.State.RUnlock()
+
+	listeners := 0
+	for _, userID := range userIDs {
+		if member, err := s.State.Member(v.guildID, userID); err == nil && member.User != nil && member.User.Bot {
+			continue
+		}
+		listeners++
+	}
+	return listeners
+}
+
+// checkVoiceListeners pauses the current song when the bot's channel empties and resumes it
+// when someone comes back. After emptyChannelTimeout with nobody back, the bot leaves.
+func checkVoiceListeners() {
+	if v.voice == nil || !v.voice.Ready {
+		return
+	}
+	listeners := voiceListeners()
+
+	presenceMutex.Lock()
+	defer presenceMutex.Unlock()
+
+	if listeners > 0 {
+		if emptyTimer != nil {
+			emptyTimer.Stop()
+			emptyTimer = nil
+		}
+		if pausedForEmpty {
+			pausedForEmpty = false
+			if v.paused && v.nowPlaying != (Track{}) {
+				v.setPaused(false)
+				log.Printf("INFO: Listeners are back, resuming [%s]", v.nowPlaying.Title)
+				s.ChannelMessageSend(v.nowPlaying.ChannelID, "**[Muse]** ▶️ Welcome back! Resuming ["+v.nowPlaying.Title+"]")
+				go refreshNowPlayingCard()
+			}
+		}
+		return
+	}
+
+	if emptyTimer != nil {
+		return // Already counting down
+	}
+
+	if v.nowPlaying != (Track{}) && !v.paused {
+		v.setPaused(true)
+		pausedForEmpty = true
+		log.Printf("INFO: Everyone left the voice channel, pausing [%s]", v.nowPlaying.Title)
+
+		notice := "**[Muse]** ⏸️ Everyone left the voice channel, so I paused [" + v.nowPlaying.Title + "]. It resumes when someone comes back."
+		if emptyChannelTimeout > 0 {
+			notice += fmt.Sprintf(" I'll leave if nobody is back in %s.", formatTimeout(emptyChannelTimeout))
+		}
+		s.ChannelMessageSend(v.nowPlaying.ChannelID, notice)
+		go refreshNowPlayingCard()
+	}
+
+	if emptyChannelTimeout > 0 {
+		emptyTimer = time.AfterFunc(emptyChannelTimeout, leaveEmptyChannel)
+	}
+}
+
+// leaveEmptyChannel leaves voice when nobody came back. The queue, with the current song picked
+// up where it was paused, is cleared in a way `undo` can bring back.
+func leaveEmptyChannel() {
+	presenceMutex.Lock()
+	emptyTimer = nil
+	pausedForEmpty = false
+	presenceMutex.Unlock()
+
+	if v.voice == nil || !v.voice.Ready || voiceListeners() > 0 {
+		return
+	}
+
+	channelID := idleChannelID
+	if v.nowPlaying != (Track{}) {
+		channelID = v.nowPlaying.ChannelID
+	}
+
+	log.Printf("INFO: Nobody came back to the voice channel, leaving")
+	saved := saveQueueAndLeave("cleared %d songs when everyone left")
+	message := "**[Muse]** :wave: Nobody came back, so I left the voice channel."
+	if saved > 0 {
+		message += fmt.Sprintf(" Type `undo` to get the %d songs back, then `play` something to start again.", saved)
+	}
+	if channelID != "" {
+		s.ChannelMessageSend(channelID, message)
+	}
+}
+
+// saveQueueAndLeave clears the queue, with the current song picked up where it is, in a way
+// `undo` can bring back, then leaves voice. It returns how many songs were saved.
+func saveQueueAndLeave(action string) int {
+	queueMutex.Lock()
+	if v.nowPlaying != (Track{}) {
+		queue = append([]Track{v.nowPlaying.resumedAt(v.elapsed(), v.playedFor())}, queue...)
+	}
+	saved := removeTracksLocked(action, func(int, Track) bool { return false })
+	unshuffledQueue = nil
+	queueMutex.Unlock()
+
+	disconnectVoice()
+	return len(saved)
+}
+
+// startIdleTimer keeps the bot in voice for idleTimeout after the queue ends. It returns false
+// when the bot should leave right away instead.
+func startIdleTimer(channelID string) bool {
+	if idleTimeout <= 0 {
+		return false
+	}
+
+	presenceMutex.Lock()
+	defer presenceMutex.Unlock()
+
+	idleChannelID = channelID
+	if idleTimer != nil {
+		idleTimer.Stop()
+	}
+	idleTimer = time.AfterFunc(idleTimeout, leaveIdleChannel)
+	return true
+}
+
+// stopIdleTimer keeps the bot in voice, something is about to play
+func stopIdleTimer() {
+	presenceMutex.Lock()
+	defer presenceMutex.Unlock()
+
+	if idleTimer != nil {
+		idleTimer.Stop()
+		idleTimer = nil
+	}
+}
+
+// leaveIdleChannel leaves voice when nothing was queued since the queue ended
+func leaveIdleChannel() {
+	presenceMutex.Lock()
+	idleTimer = nil
+	channelID := idleChannelID
+	presenceMutex.Unlock()
+
+	queueMutex.Lock()
+	queued := len(queue)
+	queueMutex.Unlock()
+	if v.voice == nil || !v.voice.Ready || v.nowPlaying != (Track{}) || getPlaybackState() || queued > 0 {
+		return
+	}
+
+	log.Printf("INFO: Idle for %s, leaving the voice channel", idleTimeout)
+	if channelID != "" {
+		s.ChannelMessageSend(channelID, fmt.Sprintf("**[Muse]** :wave: Nothing was queued for %s, leaving the voice channel.", formatTimeout(idleTimeout)))
+	}
+	disconnectVoice()
+}
+
+// formatTimeout renders a timeout like "30s", "5m" or "1h30m"
+func formatTimeout(d time.Duration) string {
+	if d < time.Minute {
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	}
+	return formatMinutes(int(d.Minutes()))
+}
+
+// disconnectVoice stops whatever is playing and leaves the voice channel
+func disconnectVoice() {
+	if v.nowPlaying != (Track{}) {
+		setPlaybackEnding(true) // playQueue winds down without error messages
+		v.setPaused(false)
+		v.stop = true
+		stopPlayback()
+	}
+	if v.voice != nil {
+		v.voice.Disconnect()
+		v.voice = nil
+	}
+}
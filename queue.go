@@ -1,13 +1,17 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"os/exec"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
+	"automuse/internal/services/audio"
+
 	"github.com/bwmarrin/discordgo"
 )
 
@@ -16,14 +20,15 @@ import (
 // - If the queue is empty, it will leave the voice channel
 func playQueue(m *discordgo.MessageCreate, isManual bool) {
 	// Prevent multiple simultaneous playQueue calls
-	if v.nowPlaying != (Song{}) {
+	if v.nowPlaying != (Track{}) {
 		log.Printf("WARN: playQueue called while already playing: %s", v.nowPlaying.Title)
 		return
 	}
+	stopIdleTimer() // Still in voice from the last queue, which is being picked up again
 
 	// Pre-download first 3 songs before starting playback
 	queueMutex.Lock()
-	initialQueue := make([]Song, len(queue))
+	initialQueue := make([]Track, len(queue))
 	copy(initialQueue, queue)
 	queueMutex.Unlock()
 
@@ -47,8 +52,8 @@ func playQueue(m *discordgo.MessageCreate, isManual bool) {
 			return
 		}
 
-		// Join voice channel once
-		v.voice, err = s.ChannelVoiceJoin(v.guildID, voiceChannelID, false, false)
+		// Join voice channel once, self-deafened like every other join since the bot never listens
+		v.voice, err = s.ChannelVoiceJoin(v.guildID, voiceChannelID, false, true)
 		if err != nil {
 			log.Printf("ERROR: Failed to join voice channel: %v", err)
 			s.ChannelMessageSend(m.ChannelID, "**[Muse]** Failed to join voice channel!")
@@ -85,92 +90,90 @@ func playQueue(m *discordgo.MessageCreate, isManual bool) {
 
 	// Iterate through the queue, playing each song
 	currentPlayingIndex := 0
+	var resumeTrack Track // Song to play again without going through the queue: after a dropped voice connection, or looping
 	for {
 		// Thread-safe queue access
 		queueMutex.Lock()
-		if len(queue) == 0 {
+		if resumeTrack != (Track{}) {
+			v.nowPlaying, resumeTrack = resumeTrack, Track{}
+		} else if len(queue) == 0 {
 			queueMutex.Unlock()
 			break
+		} else {
+			applyFairOrderLocked()
+			v.nowPlaying, queue = queue[0], queue[1:]
 		}
-		v.nowPlaying, queue = queue[0], queue[1:]
 		
-		// Track when this song started playing for history
-		v.playStartTime = time.Now()
+		// Track when this song started playing for history and the now playing card
+		v.resetPlayTime()
 
 		// Update buffer manager with current queue state
 		bufferManager.UpdateQueue(queue, currentPlayingIndex)
-
-		// Check if there's a next song for messaging
-		var hasNextSong bool
-		if len(queue) > 0 {
-			hasNextSong = true
-		}
 		queueMutex.Unlock()
 
 		log.Printf("INFO: Starting playback of: %s", v.nowPlaying.Title)
+		resetBackSteps(v.guildID, v.nowPlaying)
+		startNowPlayingCard(m.ChannelID, v.nowPlaying)
 
 		// Reset stop flag for this song
 		v.stop = false
 
-		if v.voice != nil {
-			v.voice.Speaking(true)
-		}
-
-		// Create a channel to signal when audio playback is complete
-		audioComplete := make(chan bool, 1)
+		// Play the song, this returns once it ends, is skipped or stopped, or the connection drops
+		result := playTrack()
+		skipDetected := v.stop || result.Stopped
+		voiceDropped := errors.Is(result.Err, audio.ErrVoiceLost) && !skipDetected
 
-		// Start audio playback in a separate goroutine
-		go func() {
-			if isManual {
-				v.DCA(v.nowPlaying.Title, isManual, true)
-			} else {
-				v.DCA(v.nowPlaying.VideoURL, isManual, true)
-			}
-			audioComplete <- true
-		}()
-
-		// Monitor for skip commands while audio plays
-		skipDetected := false
-		ticker := time.NewTicker(100 * time.Millisecond)
-
-	monitorLoop:
-		for {
-			select {
-			case <-ticker.C:
-				// Check if skip was called
-				if v.stop {
-					log.Printf("INFO: Skip detected in playQueue monitor, stopping current song")
-					skipDetected = true
-					ticker.Stop()
-					break monitorLoop
+		if result.Err == nil && !skipDetected {
+			// Audio finished normally
+			log.Printf("INFO: Audio playback completed normally")
+			
+			// Record song in history
+			if historyManager != nil && v.nowPlaying.Title != "" {
+				playDuration := v.nowPlaying.PlayedSoFar + v.playedFor() // Including before it was cut off
+				guildName := ""
+				if guild, err := s.State.Guild(v.guildID); err == nil {
+					guildName = guild.Name
 				}
-			case <-audioComplete:
-				// Audio finished normally
-				log.Printf("INFO: Audio playback completed normally")
-				ticker.Stop()
-				
-				// Record song in history
-				if historyManager != nil && v.nowPlaying.Title != "" {
-					playDuration := time.Since(v.playStartTime)
-					guildName := ""
-					if guild, err := s.State.Guild(v.guildID); err == nil {
-						guildName = guild.Name
-					}
-					if err := historyManager.AddEntry(v.nowPlaying, v.guildID, guildName, playDuration); err != nil {
-						log.Printf("WARN: Failed to add song to history: %v", err)
-					}
+				if err := historyManager.AddEntry(v.nowPlaying, v.guildID, guildName, playDuration); err != nil {
+					log.Printf("WARN: Failed to add song to history: %v", err)
 				}
-				
-				break monitorLoop
 			}
 		}
 
+		if voiceDropped {
+			// Pick the song up from the last frame that made it out
+			resumed := v.nowPlaying.resumedAt(result.Position, v.playedFor())
+			if recoverVoice(m.ChannelID, resumed.StartTime) {
+				finishNowPlayingCard("Connection dropped, resuming")
+				resumeTrack = resumed
+				continue
+			}
+
+			finishNowPlayingCard("Connection lost")
+			queueMutex.Lock()
+			queue = append([]Track{resumed}, queue...)
+			saved := removeTracksLocked("cleared %d songs when the voice connection was lost", func(int, Track) bool { return false })
+			unshuffledQueue = nil
+			queueMutex.Unlock()
+			s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("**[Muse]** ❌ Lost the voice connection and couldn't get back in. Type `undo` to get the %d songs back, then `play` something to start again.", len(saved)))
+			break
+		}
+
 		if skipDetected {
 			log.Printf("INFO: Skip detected, moving to next song")
+			interrupted := v.interrupting
+			v.interrupting = false
+			if isStopRequested() || isPlaybackEnding() {
+				finishNowPlayingCard("Stopped")
+			} else if interrupted {
+				finishNowPlayingCard("Interrupted, will resume")
+			} else {
+				finishNowPlayingCard("Skipped")
+			}
 			
-			// Record skipped song in history (with partial play duration)
-			if historyManager != nil && v.nowPlaying.Title != "" {
-				playDuration := time.Since(v.playStartTime)
+			// Record skipped song in history (with partial play duration); interrupted songs are recorded once they resume
+			if historyManager != nil && v.nowPlaying.Title != "" && !interrupted {
+				playDuration := v.nowPlaying.PlayedSoFar + v.playedFor()
 				guildName := ""
 				if guild, err := s.State.Guild(v.guildID); err == nil {
 					guildName = guild.Name
@@ -185,45 +188,51 @@ func playQueue(m *discordgo.MessageCreate, isManual bool) {
 			continue // Skip to next song
 		}
 
-		// Song completed normally, show next song message if queue not empty
-		queueMutex.Lock()
-		hasNextSong = len(queue) > 0 && queue[0].Title != ""
-		var nextSongTitle string
-		if hasNextSong {
-			nextSongTitle = queue[0].Title
+		if result.Err != nil {
+			finishNowPlayingCard("Couldn't play")
+			continue // Looping a song that can't be played would never end
 		}
-		queueMutex.Unlock()
 
-		if hasNextSong {
-			s.ChannelMessageSend(m.ChannelID, "**[Muse]** Next! Now playing ["+nextSongTitle+"] :loop:")
+		// Song completed normally, the next song announces itself with a new card
+		finishNowPlayingCard("Finished")
+
+		// Looping plays the song again straight away, past the fair queue rotation, from the
+		// link's offset rather than wherever it last resumed
+		if v.looping && !isStopRequested() {
+			resumeTrack = v.nowPlaying
+			resumeTrack.StartTime, resumeTrack.PlayedSoFar = resumeTrack.LinkStart, 0
 		}
 	}
 
 	// No more songs in the queue, reset and disconnect voice
 	setPlaybackEnding(true) // Set flag to prevent inappropriate error messages
-	s.ChannelMessageSend(m.ChannelID, "**[Muse]** Nothing left to play, peace! :v:")
+	finishNowPlayingCard("Stopped") // Only left over if playback was torn down mid-song
+	// Stay around for a while in case more songs are queued, unless playback was stopped
+	lingering := v.voice != nil && v.voice.Ready && !isStopRequested() && startIdleTimer(m.ChannelID)
+	if lingering {
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("**[Muse]** Nothing left to play, I'll stick around for %s in case you queue more :v:", formatTimeout(idleTimeout)))
+	} else {
+		s.ChannelMessageSend(m.ChannelID, "**[Muse]** Nothing left to play, peace! :v:")
+	}
 	v.stop = true
-	v.nowPlaying = Song{}
+	v.looping = false
+	v.nowPlaying = Track{}
 
 	queueMutex.Lock()
-	queue = []Song{}
+	queue = []Track{}
+	unshuffledQueue = nil
 	queueMutex.Unlock()
 
 	// Stop the buffer manager
 	bufferManager.StopBuffering()
 
 	// Disconnect voice connection only when queue is fully complete
-	if v.voice != nil {
+	if v.voice != nil && !lingering {
 		log.Printf("INFO: Queue finished, disconnecting from voice channel")
 		v.voice.Disconnect()
 		v.voice = nil
 	}
 
-	// Cleanup the encoder
-	if v.encoder != nil {
-		v.encoder.Cleanup()
-	}
-
 	// Reset the playback ending flag after a short delay
 	go func() {
 		time.Sleep(2 * time.Second)
@@ -236,18 +245,18 @@ func playQueue(m *discordgo.MessageCreate, isManual bool) {
 func queueSingleSong(m *discordgo.MessageCreate, link string) {
 	log.Printf("[DEBUG] Attempting to get video from link: %s", link)
 
-	// Extract video ID first for cache checking
+	if !checkUserTrackCap(m) {
+		return
+	}
+
+	// Extract video ID and start offset first for cache checking
 	var videoID string
-	if strings.Contains(link, "youtube.com/watch?v=") {
-		parts := strings.Split(link, "v=")
-		if len(parts) > 1 {
-			videoID = strings.Split(parts[1], "&")[0]
-		}
-	} else if strings.Contains(link, "youtu.be/") {
-		parts := strings.Split(link, "youtu.be/")
-		if len(parts) > 1 {
-			videoID = strings.Split(parts[1], "?")[0]
-		}
+	var startTime time.Duration
+	if parsed, err := ParseYouTubeURL(link); err == nil {
+		videoID = parsed.VideoID
+		startTime = parsed.StartTime
+		// Hand the canonical URL to the YouTube client, it doesn't understand shorts/music/live links
+		link = parsed.WatchURL()
 	}
 
 	// Check if song is already cached
@@ -262,15 +271,14 @@ func queueSingleSong(m *discordgo.MessageCreate, link string) {
 			}
 
 			// Create song with cached data
-			song = fillSongInfo(m.ChannelID, m.Author.ID, m.ID, cachedMetadata.Title, cachedMetadata.VideoID, cachedMetadata.Duration)
-			song.VideoURL = cachedMetadata.FilePath
-
-			// Thread-safe queue append
-			queueMutex.Lock()
-			queue = append(queue, song)
-			queueMutex.Unlock()
+			song = newYouTubeTrack(m, cachedMetadata.VideoID, cachedMetadata.Title, cachedMetadata.Duration)
+			song.FilePath = cachedMetadata.FilePath
+			song.startAt(startTime)
+			if !admitTrack(m, song) {
+				return
+			}
 
-			s.ChannelMessageSend(m.ChannelID, "**[Muse]** Adding cached ["+cachedMetadata.Title+"] to the Queue  :musical_note:")
+			s.ChannelMessageSend(m.ChannelID, "**[Muse]** Adding cached ["+cachedMetadata.Title+"] to the Queue"+formatStartTime(startTime)+placementNote(m.ID)+"  :musical_note:")
 			return
 		}
 	}
@@ -326,7 +334,8 @@ func queueSingleSong(m *discordgo.MessageCreate, link string) {
 	}
 
 	// Always create song with proper metadata first
-	song = fillSongInfo(m.ChannelID, m.Author.ID, m.ID, video.Title, video.ID, video.Duration.String())
+	song = newYouTubeTrack(m, video.ID, video.Title, video.Duration)
+	song.Uploader = video.Author
 
 	// Now try to get the stream URL or use cached file
 	url, err := getStreamURL(video.ID)
@@ -349,100 +358,72 @@ func queueSingleSong(m *discordgo.MessageCreate, link string) {
 		return
 	}
 
-	// Set the video URL (could be stream URL or link to original video for yt-dlp processing)
-	song.VideoURL = url
-
-	// Thread-safe queue append
-	queueMutex.Lock()
-	queue = append(queue, song)
-	queueMutex.Unlock()
+	// Play from the resolved stream; the watch URL stays as the yt-dlp fallback
+	song.StreamURL = url
+	song.startAt(startTime)
+	if !admitTrack(m, song) {
+		return
+	}
 
 	// Message the user
-	s.ChannelMessageSend(m.ChannelID, "**[Muse]** Adding ["+video.Title+"] to the Queue  :musical_note:")
+	s.ChannelMessageSend(m.ChannelID, "**[Muse]** Adding ["+video.Title+"] to the Queue"+formatStartTime(startTime)+placementNote(m.ID)+"  :musical_note:")
 }
 
-// Queue the playlist - Gets the playlist ID and searches for all individual videos & queue's them
-func queuePlaylist(playlistID string, m *discordgo.MessageCreate) {
-	nextPageToken := "" // Used to iterate through videos in a playlist
-
-	for {
-		// Retrieve next set of items in the playlist.
-		var snippet = []string{"snippet"}
-		playlistResponse := playlistItemsList(service, snippet, playlistID, nextPageToken)
-
-		for _, playlistItem := range playlistResponse.Items {
-			videoId := playlistItem.Snippet.ResourceId.VideoId
-			content := "https://www.youtube.com/watch?v=" + videoId
-
-			// Get Video Data
-			video, err := client.GetVideo(content)
-			if err != nil {
-				log.Println(err)
-			} else {
-				format := video.Formats.WithAudioChannels() // Get matches with audio channels only
-				song = fillSongInfo(m.ChannelID, m.Author.ID, m.ID, video.Title, video.ID, video.Duration.String())
-				formatList := prepSongFormat(format)
-				url, err := client.GetStreamURL(video, formatList)
-
-				if err != nil {
-					log.Println(err)
-				} else {
-					song.VideoURL = url
-					queue = append(queue, song)
-				}
-			}
-		}
-
-		// Set the token to retrieve the next page of results
-		nextPageToken = playlistResponse.NextPageToken
+// Plays the chosen song from a list provided by the search function
+func playFromSearch(input int, m *discordgo.MessageCreate) {
+	session := getSearchSession(m.GuildID, m.Author.ID)
+	if session == nil {
+		s.ChannelMessageSend(m.ChannelID, "**[Muse]** Your search results have expired, search again with `play <search terms>`")
+		return
+	}
 
-		// Nothing left, break out
-		if nextPageToken == "" {
-			break
-		}
+	if input > len(session.Results) || input <= 0 {
+		s.ChannelMessageSend(m.ChannelID, "**[Muse]** The value you entered was outside the range of the search...")
+		return // Keep the results so the user can pick again
 	}
-}
 
-// Plays the chosen song from a list provided by the search function
-func playFromSearch(input int, m *discordgo.MessageCreate) {
-	if input <= len(searchQueue) && input > 0 {
-		selectedSong := searchQueue[input-1]
-		videoURL := "https://www.youtube.com/watch?v=" + selectedSong.Id
+	selectedSong := session.Results[input-1]
+	closeSearchMessage(session, fmt.Sprintf("%s picked **%s**", session.UserName, selectedSong.Name))
+	resetSearch(m.GuildID, m.Author.ID)
+	queueSearchResult(selectedSong, m)
+}
 
-		// Check if this song is already cached before downloading
-		if cachedMetadata, exists := metadataManager.GetSong(selectedSong.Id); exists {
-			log.Printf("[INFO] Found cached song from search selection: %s", cachedMetadata.Title)
+// Queues a search result, reusing the cached file when the song was downloaded before
+func queueSearchResult(selectedSong SongSearch, m *discordgo.MessageCreate) {
+	if !checkUserTrackCap(m) {
+		return
+	}
 
-			// Check for similar songs with duplicate detection
-			similarSongs := metadataManager.FindSimilarSongs(cachedMetadata.Title, 0.8)
-			if len(similarSongs) > 1 {
-				s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("**[Muse]** Found %d similar songs in cache, using: [%s] :recycle:", len(similarSongs), cachedMetadata.Title))
-			}
+	videoURL := "https://www.youtube.com/watch?v=" + selectedSong.Id
 
-			// Create song with cached data
-			song = fillSongInfo(m.ChannelID, m.Author.ID, m.ID, cachedMetadata.Title, cachedMetadata.VideoID, cachedMetadata.Duration)
-			song.VideoURL = cachedMetadata.FilePath
+	// Check if this song is already cached before downloading
+	if cachedMetadata, exists := metadataManager.GetSong(selectedSong.Id); exists {
+		log.Printf("[INFO] Found cached song from search selection: %s", cachedMetadata.Title)
 
-			// Thread-safe queue append
-			queueMutex.Lock()
-			queue = append(queue, song)
-			queueMutex.Unlock()
+		// Check for similar songs with duplicate detection
+		similarSongs := metadataManager.FindSimilarSongs(cachedMetadata.Title, 0.8)
+		if len(similarSongs) > 1 {
+			s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("**[Muse]** Found %d similar songs in cache, using: [%s] :recycle:", len(similarSongs), cachedMetadata.Title))
+		}
 
-			s.ChannelMessageSend(m.ChannelID, "**[Muse]** Adding cached ["+cachedMetadata.Title+"] from search to the Queue  :musical_note:")
-		} else {
-			// Not cached, proceed with normal download and queue
-			queueSingleSong(m, videoURL)
+		// Create song with cached data
+		song = newYouTubeTrack(m, cachedMetadata.VideoID, cachedMetadata.Title, cachedMetadata.Duration)
+		song.FilePath = cachedMetadata.FilePath
+		if !admitTrack(m, song) {
+			return
 		}
+
+		s.ChannelMessageSend(m.ChannelID, "**[Muse]** Adding cached ["+cachedMetadata.Title+"] from search to the Queue"+placementNote(m.ID)+"  :musical_note:")
 	} else {
-		s.ChannelMessageSend(m.ChannelID, "**[Muse]** The value you entered was outside the range of the search...")
+		// Not cached, proceed with normal download and queue
+		queueSingleSong(m, videoURL)
 	}
-	searchRequested = false
 }
 
 // Plays the chosen song from the queue
 func playFromQueue(input int, m *discordgo.MessageCreate) {
 	if input <= len(queue) && input > 0 {
-		var tmp []Song
+		var tmp []Track
 		for i, value := range queue {
 			switch i {
 			case 0:
@@ -497,7 +478,7 @@ func prepDisplayQueue(commData []string, queueLenBefore int, m *discordgo.Messag
 	}
 
 	// Check if we're currently playing something - if so, we're likely not in an error state
-	if v.nowPlaying != (Song{}) {
+	if v.nowPlaying != (Track{}) {
 		log.Printf("[DEBUG] Skipping error message - something is currently playing")
 		return
 	}
@@ -517,8 +498,8 @@ func prepDisplayQueue(commData []string, queueLenBefore int, m *discordgo.Messag
 		nothingAddedMessage := "**[Muse]** Nothing was added, playlist or song was empty...\n"
 		nothingAddedMessage = nothingAddedMessage + "Note:\n"
 		nothingAddedMessage = nothingAddedMessage + "- Playlists should have the following url structure: <https://www.youtube.com/playlist?list=><PLAYLIST IDENTIFIER>\n"
-		nothingAddedMessage = nothingAddedMessage + "- Videos should have the following url structure: <https://www.youtube.com/watch?v=><VIDEO IDENTIFIER>\n"
-		nothingAddedMessage = nothingAddedMessage + "- Youtu.be links or links set at a certain time (t=#s) have not been implemented - sorry!"
+		nothingAddedMessage = nothingAddedMessage + "- Videos can be watch, youtu.be, shorts, embed, live or music.youtube.com links\n"
+		nothingAddedMessage = nothingAddedMessage + "- Links with a timestamp (t=1m30s) start playing from that point"
 		s.ChannelMessageSend(m.ChannelID, nothingAddedMessage)
 	} else {
 		log.Printf("[DEBUG] Skipping error message - command doesn't seem to be adding content: %v", commData)
@@ -528,29 +509,18 @@ func prepDisplayQueue(commData []string, queueLenBefore int, m *discordgo.Messag
 // queueWithYtDlp uses yt-dlp as a fallback for restricted videos
 func queueWithYtDlp(m *discordgo.MessageCreate, link string) bool {
 	// Extract video ID from the link
-	var videoID string
-	if strings.Contains(link, "youtube.com/watch?v=") {
-		parts := strings.Split(link, "v=")
-		if len(parts) > 1 {
-			videoID = strings.Split(parts[1], "&")[0]
-		}
-	} else if strings.Contains(link, "youtu.be/") {
-		parts := strings.Split(link, "youtu.be/")
-		if len(parts) > 1 {
-			videoID = strings.Split(parts[1], "?")[0]
-		}
-	}
-
-	if videoID == "" {
+	parsed, err := ParseYouTubeURL(link)
+	if err != nil || parsed.VideoID == "" {
 		log.Printf("[ERROR] Could not extract video ID from URL: %s", link)
 		return false
 	}
+	videoID := parsed.VideoID
+	link = parsed.WatchURL()
 
 	log.Printf("[INFO] Using yt-dlp fallback for video ID: %s", videoID)
 
 	// Use yt-dlp to get video info with comprehensive age restriction bypass
 	var output []byte
-	var err error
 	
 	// Try different bypass methods in order of preference
 	bypasses := [][]string{
@@ -594,23 +564,95 @@ func queueWithYtDlp(m *discordgo.MessageCreate, link string) bool {
 
 	// For yt-dlp fallback, we'll use the download approach since streaming might not work
 	// Create the song entry with a special flag to indicate it needs yt-dlp download
-	song = fillSongInfo(m.ChannelID, m.Author.ID, m.ID, title, videoID, duration)
-	song.VideoURL = link // Store original URL for yt-dlp processing
-
-	// Thread-safe queue append
-	queueMutex.Lock()
-	queue = append(queue, song)
-	queueMutex.Unlock()
+	song = newYouTubeTrack(m, videoID, title, songDuration(duration)) // No stream URL, yt-dlp downloads from the watch URL
+	song.startAt(parsed.StartTime)
+	if !admitTrack(m, song) {
+		return true // Handled, the user was told which limit the song hit
+	}
 
-	s.ChannelMessageSend(m.ChannelID, "**[Muse]** Adding ["+title+"] to the Queue (using fallback method) :musical_note:")
+	s.ChannelMessageSend(m.ChannelID, "**[Muse]** Adding ["+title+"] to the Queue"+formatStartTime(parsed.StartTime)+placementNote(m.ID)+" (using fallback method) :musical_note:")
 	log.Printf("[INFO] Successfully queued restricted video using yt-dlp: %s", title)
 
 	return true
 }
 
-// queuePlaylistThreaded processes playlists by downloading all songs first
-// then starting playback once everything is ready
-func queuePlaylistThreaded(playlistID string, m *discordgo.MessageCreate) {
+// playlistEntry is a single video from a flat playlist listing
+type playlistEntry struct {
+	ID       string
+	Title    string
+	Duration string // duration_string from yt-dlp, empty when the listing didn't include it
+	Channel  string // Channel or uploader name, empty when the listing didn't include it
+	Live     bool   // Live stream or upcoming premiere
+}
+
+// playlistSource describes a list that can be queued through the flat-playlist path:
+// regular playlists, Mixes (RD...), albums (OLAK5uy_...) and channel uploads
+type playlistSource struct {
+	URL   string // URL handed to yt-dlp
+	Kind  string // User-facing name used in messages ("playlist", "Mix", "album", "channel")
+	Limit int    // Only list the first Limit entries, 0 lists everything
+}
+
+// playlistSourceFromLink builds the source for a YouTube link that carries a list= parameter
+func playlistSourceFromLink(link *YouTubeLink) playlistSource {
+	return playlistSource{URL: link.ListingURL(), Kind: link.ListKind()}
+}
+
+// fetchPlaylistEntries lists the videos of a playlist with yt-dlp without resolving each video.
+// A limit above zero stops the listing after that many entries.
+func fetchPlaylistEntries(playlistURL string, limit int) ([]playlistEntry, error) {
+	// One line per video: id, duration, channel, live status and title separated by tabs (title last, it may contain anything)
+	args := []string{
+		"--flat-playlist",
+		"--print", "%(id)s\t%(duration_string)s\t%(channel,uploader)s\t%(live_status)s\t%(title)s",
+		"--no-warnings",
+		"--age-limit", "99", // Bypass age restrictions
+		"--no-check-certificate", // Skip SSL verification if needed
+	}
+	if limit > 0 {
+		args = append(args, "--playlist-end", strconv.Itoa(limit))
+	}
+	args = append(args, playlistURL)
+	cmd := exec.Command("yt-dlp", args...)
+
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("yt-dlp failed to list playlist: %w", err)
+	}
+
+	var entries []playlistEntry
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		fields := strings.SplitN(strings.TrimSpace(line), "\t", 5)
+		if len(fields) < 5 || fields[0] == "" {
+			continue
+		}
+
+		liveStatus := printedField(fields[3])
+		entries = append(entries, playlistEntry{
+			ID:       strings.TrimSpace(fields[0]),
+			Title:    strings.TrimSpace(fields[4]),
+			Duration: printedField(fields[1]),
+			Channel:  printedField(fields[2]),
+			Live:     liveStatus == "is_live" || liveStatus == "is_upcoming",
+		})
+	}
+
+	return entries, nil
+}
+
+// printedField cleans a yt-dlp --print field, mapping the "NA" placeholder for missing values to ""
+func printedField(value string) string {
+	value = strings.TrimSpace(value)
+	if value == "NA" {
+		return ""
+	}
+	return value
+}
+
+// queuePlaylistThreaded queues the first playlist entry and starts playback as soon as the
+// flat listing returns, then appends the remaining entries in order while durations that
+// were missing from the listing are resolved in the background
+func queuePlaylistThreaded(source playlistSource, opts PlaylistOptions, m *discordgo.MessageCreate) {
 	// Check user-specific cooldown to prevent spam
 	userKey := m.Author.ID + ":playlist"
 	if isCommandActive(userKey, "playlist") {
@@ -632,208 +674,183 @@ func queuePlaylistThreaded(playlistID string, m *discordgo.MessageCreate) {
 		return
 	}
 
-	log.Printf("INFO: Starting threaded playlist processing for: %s", playlistID)
-
-	// Use yt-dlp as primary method for playlist processing due to YouTube client issues
-	playlistURL := "https://www.youtube.com/playlist?list=" + playlistID
-	log.Printf("INFO: Using yt-dlp for playlist processing: %s", playlistURL)
+	if !checkUserTrackCap(m) {
+		return
+	}
 
-	s.ChannelMessageSend(m.ChannelID, "**[Muse]** 🔍 Scanning playlist with enhanced method (this may take a moment)...")
+	log.Printf("INFO: Starting threaded %s processing for: %s", source.Kind, source.URL)
 
-	// Get playlist info using yt-dlp with age restriction bypass
-	cmd := exec.Command("yt-dlp",
-		"--flat-playlist",
-		"--print", "id",
-		"--print", "title",
-		"--no-warnings",
-		"--age-limit", "99",  // Bypass age restrictions
-		"--no-check-certificate", // Skip SSL verification if needed
-		playlistURL)
+	s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("**[Muse]** 🔍 Scanning %s with enhanced method (this may take a moment)...", source.Kind))
 
-	output, err := cmd.Output()
+	// Use yt-dlp as primary method for playlist processing due to YouTube client issues
+	videoData, err := fetchPlaylistEntries(source.URL, source.Limit)
 	if err != nil {
-		log.Printf("ERROR: yt-dlp failed to get playlist info: %v", err)
-		s.ChannelMessageSend(m.ChannelID, "**[Muse]** ❌ Failed to access playlist. It may be private, deleted, or region-restricted.")
+		log.Printf("ERROR: %v", err)
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("**[Muse]** ❌ Failed to access %s. It may be private, deleted, or region-restricted.", source.Kind))
 		return
 	}
 
-	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
-	if len(lines) < 2 {
-		s.ChannelMessageSend(m.ChannelID, "**[Muse]** ❌ Playlist appears to be empty or inaccessible.")
+	if len(videoData) == 0 {
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("**[Muse]** No videos found in %s or it is private.", source.Kind))
 		return
 	}
 
-	// Parse yt-dlp output (alternating lines: id, title, id, title, ...)
-	var videoData []struct {
-		ID    string
-		Title string
-	}
-
-	for i := 0; i < len(lines); i += 2 {
-		if i+1 < len(lines) {
-			videoData = append(videoData, struct {
-				ID    string
-				Title string
-			}{
-				ID:    strings.TrimSpace(lines[i]),
-				Title: strings.TrimSpace(lines[i+1]),
-			})
+	// Apply range / filter / ordering options before any size checks
+	if opts.IsSet() {
+		totalEntries := len(videoData)
+		videoData = opts.Apply(videoData)
+		log.Printf("INFO: Playlist options (%s) selected %d/%d entries", opts.Describe(), len(videoData), totalEntries)
+		if len(videoData) == 0 {
+			s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("**[Muse]** No playlist entries matched your selection (%s). The playlist has %d songs.", opts.Describe(), totalEntries))
+			return
 		}
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("**[Muse]** Selected %d of %d songs (%s)", len(videoData), totalEntries, opts.Describe()))
 	}
 
-	if len(videoData) == 0 {
-		s.ChannelMessageSend(m.ChannelID, "**[Muse]** No videos found in playlist or playlist is private.")
-		return
-	}
-
-	// Check if playlist is too large
-	if len(videoData) > 100 {
-		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("**[Muse]** 🚫 Playlist too large! (%d songs). Maximum allowed is 100 songs to prevent system overload.", len(videoData)))
-		log.Printf("WARN: Playlist rejected - too large (%d songs)", len(videoData))
-		return
+	songs := make([]Track, 0, len(videoData))
+	for _, video := range videoData {
+		song := newYouTubeTrack(m, video.ID, video.Title, songDuration(video.Duration)) // yt-dlp downloads from the watch URL
+		song.Uploader = video.Channel
+		songs = append(songs, song)
 	}
 
-	// Check if adding this playlist would exceed queue limit
+	// Only queue the entries that fit the user's limits on this server. The limits are checked and
+	// the songs queued in one go, so a second request from the same user can't slip in between.
 	queueMutex.Lock()
+	songs, report := admitTracksLocked(m.GuildID, m.Author.ID, songs, true)
 	currentQueueSize := len(queue)
+	interrupt := false
+	rejected := ""
+	switch {
+	case len(songs) == 0:
+		rejected = fmt.Sprintf("**[Muse]** 🚫 None of the %d songs can be added: %s.", len(videoData), report.Describe(settingsFor(m.GuildID)))
+	case len(songs) > maxPlaylistSize:
+		rejected = fmt.Sprintf("**[Muse]** 🚫 Playlist too large! (%d songs). Maximum allowed is %d songs to prevent system overload. Pick a range instead, e.g. `play <playlist-url> 1-%d`", len(songs), maxPlaylistSize, maxPlaylistSize)
+		log.Printf("WARN: Playlist rejected - too large (%d songs)", len(songs))
+	case currentQueueSize+len(songs) > maxQueueSize:
+		rejected = fmt.Sprintf("**[Muse]** 🚫 Adding this playlist (%d songs) would exceed the maximum queue size (%d). Current queue: %d songs.", len(songs), maxQueueSize, currentQueueSize)
+		log.Printf("WARN: Playlist rejected - would exceed queue limit")
+	default:
+		// Playnext/playnow requests keep the playlist together at the front
+		interrupt = enqueueTracksLocked(m, songs...)
+	}
 	queueMutex.Unlock()
+	interruptForPlayNow(interrupt)
 
-	if currentQueueSize+len(videoData) > maxQueueSize {
-		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("**[Muse]** 🚫 Adding this playlist (%d songs) would exceed the maximum queue size (%d). Current queue: %d songs.", len(videoData), maxQueueSize, currentQueueSize))
-		log.Printf("WARN: Playlist rejected - would exceed queue limit")
+	if rejected != "" {
+		s.ChannelMessageSend(m.ChannelID, rejected)
 		return
 	}
+	if report.Rejected() > 0 {
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("**[Muse]** Skipped %d songs: %s.", report.Rejected(), report.Describe(settingsFor(m.GuildID))))
+	}
+	if warning := report.Warning(); warning != "" {
+		s.ChannelMessageSend(m.ChannelID, warning)
+	}
 
 	log.Printf("INFO: Found %d videos in playlist using yt-dlp", len(videoData))
-	s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("**[Muse]** Found %d videos! Processing playlist... ⏳", len(videoData)))
 
-	// Process all videos and queue them before starting playback
-	songsProcessed := 0
-	successfullyQueued := 0
+	startedPlayback := false
+	if v.nowPlaying == (Track{}) && !getPlaybackState() {
+		log.Printf("INFO: Starting playback with first playlist entry: %s", songs[0].Title)
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("**[Muse]** Found %d videos! Starting [%s] while the rest are queued... :infinity:", len(songs), songs[0].Title))
+		v.currentUserID = m.Author.ID
+		startedPlayback = true
 
-	// Reduced concurrent processing to prevent overwhelming
-	maxConcurrent := 2
-	semaphore := make(chan struct{}, maxConcurrent)
+		go func() {
+			defer RecoverWithErrorHandler(errorHandler, m.ChannelID)
+			joinVoiceChannel()
+			prepFirstSongEntered(m, false)
+		}()
+	} else {
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("**[Muse]** Found %d videos! Adding them to the queue... :infinity:", len(songs)))
+	}
+
+	log.Printf("INFO: Playlist processing complete. Queued %d/%d songs", len(songs), len(videoData))
+	s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("**[Muse]** ✅ Ready! Added %d songs from the %s to queue. 🎵", len(songs), source.Kind))
 
-	// Structure to hold results with their original index
-	type processResult struct {
-		index   int
-		song    Song
-		success bool
+	// Resolve durations the flat listing didn't provide without blocking playback
+	go fillMissingDurations(songs)
+
+	if !startedPlayback {
+		// Something is already playing, just notify that songs were added
+		log.Printf("INFO: Playback already in progress, playlist songs added to queue")
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("**[Muse]** ✅ The %s was added to queue! Songs will play after current music. 🎵", source.Kind))
 	}
 
-	// Channel to collect results from goroutines
-	resultChan := make(chan processResult, len(videoData))
+	log.Printf("INFO: Threaded playlist processing completed for %d videos", len(videoData))
+}
 
-	// Process all videos in parallel
-	for i, video := range videoData {
-		// Check if we should stop processing (user might have stopped)
-		if isStopRequested() || isPlaybackEnding() {
-			log.Printf("INFO: Stopping playlist processing due to stop request")
-			s.ChannelMessageSend(m.ChannelID, "**[Muse]** Playlist processing stopped.")
-			return
+// fillMissingDurations looks up durations for songs queued without one and updates
+// the queued entries in place, and the live card for the current song
+func fillMissingDurations(songs []Track) {
+	maxConcurrent := 2
+	semaphore := make(chan struct{}, maxConcurrent)
+	var wg sync.WaitGroup
+
+	for _, song := range songs {
+		if song.Duration > 0 {
+			continue
+		}
+		if isStopRequested() {
+			break
 		}
 
+		wg.Add(1)
 		semaphore <- struct{}{} // Acquire semaphore
-
-		go func(videoID, title string, index int) {
+		go func(song Track) {
+			defer wg.Done()
 			defer func() { <-semaphore }() // Release semaphore
 
-			videoURL := "https://www.youtube.com/watch?v=" + videoID
-			log.Printf("INFO: Processing video %d/%d: %s - %s", index+1, len(videoData), title, videoID)
-
-			// Get duration using yt-dlp (more reliable than YouTube client)
 			durationCmd := exec.Command("yt-dlp",
 				"--no-download",
 				"--print", "duration_string",
 				"--no-warnings",
-				"--age-limit", "99",  // Bypass age restrictions
+				"--age-limit", "99", // Bypass age restrictions
 				"--no-check-certificate", // Skip SSL verification if needed
-				videoURL)
+				song.URL)
 
 			durationOutput, err := durationCmd.Output()
-			duration := "Unknown"
-			if err == nil {
-				duration = strings.TrimSpace(string(durationOutput))
-			}
-
-			// Create song with yt-dlp metadata
-			song := fillSongInfo(m.ChannelID, m.Author.ID, m.ID, title, videoID, duration)
-			song.VideoURL = videoURL // Store original URL for yt-dlp processing
-
-			log.Printf("[INFO] Successfully processed (%d/%d): %s", index+1, len(videoData), title)
-			resultChan <- processResult{index: index, song: song, success: true}
-
-		}(video.ID, video.Title, i)
-	}
-
-	// Collect all results with timeout protection
-	results := make([]processResult, len(videoData))
-	timeout := time.NewTimer(5 * time.Minute) // 5 minute timeout for playlist processing
-	defer timeout.Stop()
-
-	for i := 0; i < len(videoData); i++ {
-		select {
-		case result := <-resultChan:
-			results[result.index] = result
-			songsProcessed++
-
-			if result.success {
-				successfullyQueued++
+			if err != nil {
+				log.Printf("DEBUG: Could not resolve duration for %s: %v", song.VideoID, err)
+				return
 			}
-
-			// Send progress updates less frequently to avoid spam
-			if songsProcessed%10 == 0 || songsProcessed == len(videoData) {
-				s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("**[Muse]** Processed %d/%d songs from playlist... 🎵", songsProcessed, len(videoData)))
+			if duration, ok := parseSongDuration(string(durationOutput)); ok {
+				updateQueuedDuration(song.VideoID, duration)
 			}
-
-		case <-timeout.C:
-			s.ChannelMessageSend(m.ChannelID, "**[Muse]** ⏰ Playlist processing timed out. Some songs may not have been added.")
-			log.Printf("ERROR: Playlist processing timed out after 5 minutes")
-			goto processResults
-		}
+		}(song)
 	}
 
-processResults:
-	log.Printf("INFO: Playlist processing complete. Successfully queued %d/%d songs", successfullyQueued, len(videoData))
-
-	if successfullyQueued == 0 {
-		s.ChannelMessageSend(m.ChannelID, "**[Muse]** No songs could be processed from the playlist. All videos may be unavailable or restricted.")
-		return
-	}
+	wg.Wait()
+}
 
-	// Add successful songs to queue in original playlist order
+// updateQueuedDuration sets the duration on every queued entry with the given video ID that
+// lacks one, then takes out those that turn out to break the server's length limits. The current song belongs
+// to the playback loop, so only its now playing card learns the duration.
+func updateQueuedDuration(videoID string, duration time.Duration) {
 	queueMutex.Lock()
-	for _, result := range results {
-		if result.success {
-			queue = append(queue, result.song)
+	filled := make(map[trackKey]bool)
+	for i := range queue {
+		if queue[i].VideoID == videoID && queue[i].Duration == 0 {
+			queue[i].Duration = duration
+			filled[keyOf(queue[i])] = true
 		}
 	}
+	dropped := dropOverLengthLocked(v.guildID, filled)
 	queueMutex.Unlock()
 
-	// Now start playback with all songs queued
-	s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("**[Muse]** ✅ Playlist ready! Added %d songs to queue. 🎵", successfullyQueued))
-
-	// **ALWAYS SHOW COMPLETE QUEUE** after playlist processing
-	log.Printf("INFO: Displaying complete queue after playlist processing")
-	displayQueue(m)
-
-	// **SMART PLAYBACK MANAGEMENT** - Start playback only if nothing is playing
-	queueMutex.Lock()
-	currentQueueSize = len(queue)
-	queueMutex.Unlock()
-
-	if v.nowPlaying == (Song{}) && currentQueueSize >= 1 && !getPlaybackState() {
-		// Nothing is playing, start playback
-		log.Printf("INFO: Starting playback for playlist with %d songs", currentQueueSize)
-		joinVoiceChannel()
-		prepFirstSongEntered(m, false)
-	} else if getPlaybackState() || v.nowPlaying != (Song{}) {
-		// Something is already playing, just notify that songs were added
-		log.Printf("INFO: Playback already in progress, playlist songs added to queue")
-		s.ChannelMessageSend(m.ChannelID, "**[Muse]** ✅ Playlist added to queue! Songs will play after current music. 🎵")
+	if card := liveNowPlayingCard(); card != nil {
+		card.fillDuration(videoID, duration)
 	}
 
-	log.Printf("INFO: Threaded playlist processing completed for %d videos", len(videoData))
+	if len(dropped) == 0 {
+		return
+	}
+	settings := settingsFor(v.guildID)
+	for _, rejected := range dropped {
+		log.Printf("INFO: Removed [%s] from the queue once its duration was known", rejected.Track.Title)
+		reason := rejectionReason(rejected.Report, settings, rejected.Track)
+		s.ChannelMessageSend(rejected.Track.ChannelID, fmt.Sprintf("**[Muse]** 🚫 Took [%s] out of the queue: %s.", rejected.Track.Title, reason))
+	}
+	go refreshNowPlayingCard()
 }
-
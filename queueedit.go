@@ -0,0 +1,342 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const queueUndoWindow = 5 * time.Minute // How long `undo` can revert the last queue edit
+
+// queueEdit remembers the queue from before an edit so `undo` can revert it
+type queueEdit struct {
+	Action  string  // What was done, e.g. "removed 3 songs"
+	Before  []Track // Queue order before the edit
+	Removed []Track // Tracks the edit took out of the queue
+	At      time.Time
+}
+
+// lastQueueEdit is the edit `undo` reverts, nil when there is none. Guarded by queueMutex.
+var lastQueueEdit *queueEdit
+
+// recordQueueEditLocked remembers the queue as it is now, before an edit; queueMutex must be held
+func recordQueueEditLocked(action string, removed []Track) {
+	before := make([]Track, len(queue))
+	copy(before, queue)
+	lastQueueEdit = &queueEdit{Action: action, Before: before, Removed: removed, At: time.Now()}
+}
+
+// undoOrder rebuilds the queue from before an edit. Tracks the edit removed come back, tracks
+// played since stay gone and tracks queued since keep their order at the end.
+func undoOrder(edit *queueEdit, current []Track) []Track {
+	available := make(map[trackKey]int)
+	for _, track := range current {
+		available[keyOf(track)]++
+	}
+	for _, track := range edit.Removed {
+		available[keyOf(track)]++
+	}
+
+	restored := make([]Track, 0, len(current)+len(edit.Removed))
+	for _, track := range edit.Before {
+		if key := keyOf(track); available[key] > 0 {
+			restored = append(restored, track)
+			available[key]--
+		}
+	}
+
+	// Tracks queued after the edit
+	for _, track := range current {
+		if key := keyOf(track); available[key] > 0 {
+			restored = append(restored, track)
+			available[key]--
+		}
+	}
+	return restored
+}
+
+// removeTracksLocked takes the tracks matching keep == false out of the queue and records the edit;
+// queueMutex must be held. It returns the removed tracks.
+func removeTracksLocked(action string, keep func(index int, track Track) bool) []Track {
+	var kept, removed []Track
+	for i, track := range queue {
+		if keep(i, track) {
+			kept = append(kept, track)
+		} else {
+			removed = append(removed, track)
+		}
+	}
+	if len(removed) == 0 {
+		return nil
+	}
+
+	recordQueueEditLocked(fmt.Sprintf(action, len(removed)), removed)
+	queue = kept
+	if queue == nil {
+		queue = []Track{}
+	}
+	return removed
+}
+
+// parsePositions reads 1-based queue positions like "3", "3-10" or "2,5,9", or any mix of them
+func parsePositions(spec string, length int) (map[int]bool, error) {
+	positions := make(map[int]bool)
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		from, to := part, part
+		if i := strings.Index(part, "-"); i > 0 {
+			from, to = part[:i], part[i+1:]
+		}
+		start, err1 := strconv.Atoi(from)
+		end, err2 := strconv.Atoi(to)
+		if err1 != nil || err2 != nil {
+			return nil, fmt.Errorf("%q isn't a position or range like `3` or `3-10`", part)
+		}
+		if start > end {
+			start, end = end, start
+		}
+		if start < 1 || end > length {
+			return nil, fmt.Errorf("positions must be between 1 and %d", length)
+		}
+		for position := start; position <= end; position++ {
+			positions[position] = true
+		}
+	}
+	if len(positions) == 0 {
+		return nil, fmt.Errorf("no positions given")
+	}
+	return positions, nil
+}
+
+// mentionedUserID returns the user ID from a <@id> or <@!id> mention, or "" if it isn't one
+func mentionedUserID(arg string) string {
+	if !strings.HasPrefix(arg, "<@") || !strings.HasSuffix(arg, ">") {
+		return ""
+	}
+	return strings.TrimPrefix(strings.TrimSuffix(strings.TrimPrefix(arg, "<@"), ">"), "!")
+}
+
+// dedupeKey identifies the song behind a track, so the same song queued twice matches
+func dedupeKey(track Track) string {
+	if track.VideoID != "" {
+		return "yt:" + track.VideoID
+	}
+	return "file:" + track.FilePath
+}
+
+// findUnavailableTracks returns the tracks that can't be played anymore: local files that are gone
+// and YouTube videos that were deleted or made private. The second result is false when YouTube
+// couldn't be asked (no API key, quota exhausted), so only local files were checked.
+func findUnavailableTracks(tracks []Track) (map[trackKey]bool, bool) {
+	unavailable := make(map[trackKey]bool)
+	var videoIDs []string
+	for _, track := range tracks {
+		if track.VideoID == "" {
+			if _, err := os.Stat(track.FilePath); err != nil {
+				unavailable[keyOf(track)] = true
+			}
+			continue
+		}
+		videoIDs = append(videoIDs, track.VideoID)
+	}
+
+	if len(videoIDs) == 0 {
+		return unavailable, true
+	}
+	if youtubeAPI == nil {
+		return unavailable, false
+	}
+
+	// videos.list takes up to 50 IDs and leaves out videos that are deleted or private
+	playable := make(map[string]bool)
+	for start := 0; start < len(videoIDs); start += 50 {
+		end := min(start+50, len(videoIDs))
+		response, err := youtubeAPI.Videos(ctx, []string{"status"}, videoIDs[start:end])
+		if err != nil {
+			log.Printf("WARN: Failed to check video availability: %v", err)
+			return unavailable, false
+		}
+		for _, item := range response.Items {
+			if item.Status == nil || item.Status.PrivacyStatus == "private" {
+				continue
+			}
+			switch item.Status.UploadStatus {
+			case "deleted", "failed", "rejected":
+				continue
+			}
+			playable[item.Id] = true
+		}
+	}
+
+	for _, track := range tracks {
+		if track.VideoID != "" && !playable[track.VideoID] {
+			unavailable[keyOf(track)] = true
+		}
+	}
+	return unavailable, true
+}
+
+// removeCommand removes songs from the queue: `remove 3`, `remove 3-10`, `remove 2,5,9`,
+// `remove @user`, `remove dupes`, `remove unavailable` or `remove <part of a title>`
+func removeCommand(s *discordgo.Session, m *discordgo.MessageCreate, args []string) {
+	if len(args) == 0 {
+		s.ChannelMessageSend(m.ChannelID, "**[Muse]** Usage: `remove 3`, `remove 3-10`, `remove 2,5,9`, `remove @user`, `remove dupes` or `remove unavailable`")
+		return
+	}
+
+	var removed []Track
+	var target string
+
+	switch spec := strings.ToLower(args[0]); {
+	case spec == "dupes" || spec == "duplicates":
+		target = "duplicate"
+		seen := make(map[string]bool)
+		queueMutex.Lock()
+		removed = removeTracksLocked("removed %d duplicates", func(_ int, track Track) bool {
+			key := dedupeKey(track)
+			if seen[key] {
+				return false
+			}
+			seen[key] = true
+			return true
+		})
+		queueMutex.Unlock()
+
+	case spec == "unavailable":
+		target = "unavailable"
+		queueMutex.Lock()
+		snapshot := make([]Track, len(queue))
+		copy(snapshot, queue)
+		queueMutex.Unlock()
+
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("**[Muse]** :mag: Checking %d songs...", len(snapshot)))
+		unavailable, checkedYouTube := findUnavailableTracks(snapshot)
+		if !checkedYouTube {
+			s.ChannelMessageSend(m.ChannelID, "**[Muse]** ⚠️ Couldn't ask YouTube which videos are gone, only local files were checked.")
+		}
+
+		queueMutex.Lock()
+		removed = removeTracksLocked("removed %d unavailable songs", func(_ int, track Track) bool {
+			return !unavailable[keyOf(track)]
+		})
+		queueMutex.Unlock()
+
+	case mentionedUserID(args[0]) != "":
+		userID := mentionedUserID(args[0])
+		name := "that person's"
+		for _, user := range m.Mentions {
+			if user.ID == userID {
+				name = user.Username + "'s"
+			}
+		}
+		target = name
+		queueMutex.Lock()
+		removed = removeTracksLocked("removed %d of "+strings.ReplaceAll(name, "%", "%%")+" songs", func(_ int, track Track) bool {
+			return track.RequestedBy != userID
+		})
+		queueMutex.Unlock()
+
+	default:
+		queueMutex.Lock()
+		if len(queue) == 0 {
+			queueMutex.Unlock()
+			s.ChannelMessageSend(m.ChannelID, "**[Muse]** There is no queue to remove songs from.")
+			return
+		}
+		positions, err := parsePositions(strings.Join(args, ","), len(queue))
+		if err != nil && strings.IndexFunc(strings.Join(args, ""), unicode.IsLetter) >= 0 {
+			// Not positions, so a title fragment that has to pick out a single song
+			position, problem := resolveQueueTitleLocked(strings.Join(args, " "))
+			if position == 0 {
+				queueMutex.Unlock()
+				s.ChannelMessageSend(m.ChannelID, problem)
+				return
+			}
+			positions, err = map[int]bool{position: true}, nil
+		}
+		if err != nil {
+			queueMutex.Unlock()
+			s.ChannelMessageSend(m.ChannelID, "**[Muse]** Can't remove that: "+err.Error()+".")
+			return
+		}
+		removed = removeTracksLocked("removed %d songs", func(index int, _ Track) bool {
+			return !positions[index+1]
+		})
+		queueMutex.Unlock()
+
+		if len(removed) == 1 {
+			s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("**[Muse]** Removed %s. Type `undo` to put it back.", removed[0].Title))
+			go refreshNowPlayingCard()
+			return
+		}
+	}
+
+	if len(removed) == 0 {
+		if target == "" {
+			target = "matching"
+		}
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("**[Muse]** No %s songs in the queue, nothing was removed.", target))
+		return
+	}
+
+	s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("**[Muse]** :wastebasket: Removed %d songs: %s. Type `undo` to put them back.", len(removed), summarizeTitles(removed, 5)))
+	go refreshNowPlayingCard()
+}
+
+// summarizeTitles lists up to limit titles, e.g. "A, B, C and 4 more"
+func summarizeTitles(tracks []Track, limit int) string {
+	var titles []string
+	for i, track := range tracks {
+		if i == limit {
+			return strings.Join(titles, ", ") + fmt.Sprintf(" and %d more", len(tracks)-limit)
+		}
+		titles = append(titles, truncateText(track.Title, 40))
+	}
+	return strings.Join(titles, ", ")
+}
+
+// clearQueueCommand empties the queue but keeps the current song playing
+func clearQueueCommand(s *discordgo.Session, m *discordgo.MessageCreate) {
+	queueMutex.Lock()
+	removed := removeTracksLocked("cleared %d songs", func(int, Track) bool { return false })
+	unshuffledQueue = nil
+	queueMutex.Unlock()
+
+	if len(removed) == 0 {
+		s.ChannelMessageSend(m.ChannelID, "**[Muse]** The queue is already empty.")
+		return
+	}
+
+	s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("**[Muse]** :wastebasket: Cleared %d songs from the queue, the current song keeps playing. Type `undo` to bring them back.", len(removed)))
+	go refreshNowPlayingCard()
+}
+
+// undoQueueCommand reverts the last remove, move, shuffle or clear
+func undoQueueCommand(s *discordgo.Session, m *discordgo.MessageCreate) {
+	queueMutex.Lock()
+	edit := lastQueueEdit
+	if edit == nil || time.Since(edit.At) > queueUndoWindow {
+		lastQueueEdit = nil
+		queueMutex.Unlock()
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("**[Muse]** Nothing to undo, only queue changes from the last %d minutes can be undone.", int(queueUndoWindow.Minutes())))
+		return
+	}
+
+	queue = undoOrder(edit, queue)
+	lastQueueEdit = nil
+	applyFairOrderLocked()
+	queueMutex.Unlock()
+
+	s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("**[Muse]** :leftwards_arrow_with_hook: Undid the last change (%s).", edit.Action))
+	go refreshNowPlayingCard()
+}
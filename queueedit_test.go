@@ -0,0 +1,96 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParsePositions(t *testing.T) {
+	tests := []struct {
+		spec string
+		want []int
+		ok   bool
+	}{
+		{"3", []int{3}, true},
+		{"3-5", []int{3, 4, 5}, true},
+		{"5-3", []int{3, 4, 5}, true},
+		{"2,5,9", []int{2, 5, 9}, true},
+		{" 1 , 4-5 ", []int{1, 4, 5}, true},
+		{"2,2-3", []int{2, 3}, true},
+		{"1,,3", []int{1, 3}, true},
+		{"10", []int{10}, true},
+		{"", nil, false},
+		{",", nil, false},
+		{"0", nil, false},
+		{"11", nil, false},
+		{"9-11", nil, false},
+		{"-3", nil, false},
+		{"3-", nil, false},
+		{"a", nil, false},
+		{"1-b", nil, false},
+	}
+
+	for _, tt := range tests {
+		positions, err := parsePositions(tt.spec, 10)
+		if (err == nil) != tt.ok {
+			t.Errorf("parsePositions(%q) error = %v, want ok=%t", tt.spec, err, tt.ok)
+			continue
+		}
+		if !tt.ok {
+			continue
+		}
+		want := make(map[int]bool)
+		for _, position := range tt.want {
+			want[position] = true
+		}
+		if !reflect.DeepEqual(positions, want) {
+			t.Errorf("parsePositions(%q) = %v, want %v", tt.spec, positions, want)
+		}
+	}
+}
+
+func TestUndoOrder(t *testing.T) {
+	tracks := queuedTracks("u1:Abba", "u1:Blur", "u2:Cher", "u2:Dido", "u3:Enya")
+	a, b, c, d, e := tracks[0], tracks[1], tracks[2], tracks[3], tracks[4]
+
+	tests := []struct {
+		name    string
+		edit    queueEdit
+		current []Track
+		want    string
+	}{
+		{"removed come back", queueEdit{Before: []Track{a, b, c}, Removed: []Track{b}}, []Track{a, c}, "abc"},
+		{"cleared", queueEdit{Before: []Track{a, b}, Removed: []Track{a, b}}, nil, "ab"},
+		{"reordered", queueEdit{Before: []Track{a, b, c}}, []Track{c, a, b}, "abc"},
+		{"played since", queueEdit{Before: []Track{a, b, c}, Removed: []Track{c}}, []Track{b}, "bc"},
+		{"queued since", queueEdit{Before: []Track{a, b}, Removed: []Track{a}}, []Track{b, e, d}, "abed"},
+		{"queued twice", queueEdit{Before: []Track{a, a, b}, Removed: []Track{a}}, []Track{a, b}, "aab"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := messageIDs(undoOrder(&tt.edit, tt.current)); got != tt.want {
+				t.Errorf("undoOrder() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMentionedUserID(t *testing.T) {
+	tests := []struct {
+		arg  string
+		want string
+	}{
+		{"<@123>", "123"},
+		{"<@!123>", "123"},
+		{"123", ""},
+		{"@someone", ""},
+		{"<#123>", ""},
+	}
+
+	for _, tt := range tests {
+		if got := mentionedUserID(tt.arg); got != tt.want {
+			t.Errorf("mentionedUserID(%q) = %q, want %q", tt.arg, got, tt.want)
+		}
+	}
+}
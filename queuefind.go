@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	findSimilarityThreshold = 0.3 // Title similarity needed to count as a match when the text isn't part of the title
+	findResultLimit         = 10  // Matches listed by `find`
+)
+
+// queueMatch is a queued track matching a search text
+type queueMatch struct {
+	Position int // 1-based queue position
+	Track    Track
+	Exact    bool    // The text appears in the title as is
+	Score    float64 // Title similarity, 1 for exact matches
+}
+
+// findInQueueLocked returns the queued tracks whose title contains the text or is similar to it,
+// using the title similarity of the download cache. Exact matches come first, then the most
+// similar, each in queue order. queueMutex must be held.
+func findInQueueLocked(text string) []queueMatch {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil
+	}
+
+	lower := strings.ToLower(text)
+	textHash := generateTitleHash(text)
+
+	var matches []queueMatch
+	for i, track := range queue {
+		if strings.Contains(strings.ToLower(track.Title), lower) {
+			matches = append(matches, queueMatch{Position: i + 1, Track: track, Exact: true, Score: 1})
+			continue
+		}
+		if score := calculateSimilarity(textHash, generateTitleHash(track.Title)); score >= findSimilarityThreshold {
+			matches = append(matches, queueMatch{Position: i + 1, Track: track, Score: score})
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].Exact != matches[j].Exact {
+			return matches[i].Exact
+		}
+		return matches[i].Score > matches[j].Score
+	})
+	return matches
+}
+
+// resolveQueueTitleLocked finds the one queued track a title fragment refers to. Exact matches win
+// over similar titles; when several tracks match equally, the fragment is ambiguous. It returns the
+// 1-based position, or 0 and a message for the user. queueMutex must be held.
+func resolveQueueTitleLocked(fragment string) (int, string) {
+	matches := findInQueueLocked(fragment)
+	if len(matches) == 0 {
+		return 0, fmt.Sprintf("**[Muse]** Nothing in the queue matches %q.", fragment)
+	}
+
+	// Only the best kind of match counts: exact ones if there are any, otherwise the most similar
+	best := matches[:1]
+	for _, match := range matches[1:] {
+		if match.Exact == matches[0].Exact && (match.Exact || match.Score == matches[0].Score) {
+			best = append(best, match)
+		}
+	}
+	if len(best) == 1 {
+		return best[0].Position, ""
+	}
+
+	return 0, fmt.Sprintf("**[Muse]** %q matches %d songs, use a position number instead:\n%s", fragment, len(best), formatQueueMatches(best, 5))
+}
+
+// formatQueueMatches lists matches with their queue positions, up to limit of them
+func formatQueueMatches(matches []queueMatch, limit int) string {
+	var lines strings.Builder
+	for i, match := range matches {
+		if i == limit {
+			fmt.Fprintf(&lines, "...and %d more\n", len(matches)-limit)
+			break
+		}
+		fmt.Fprintf(&lines, "`%d.` %s • <@%s>\n", match.Position, truncateText(match.Track.Title, 80), match.Track.RequestedBy)
+	}
+	return lines.String()
+}
+
+// findCommand lists the queue positions of songs matching the text: `find <text>`
+func findCommand(s *discordgo.Session, m *discordgo.MessageCreate, args []string) {
+	text := strings.Join(args, " ")
+	if strings.TrimSpace(text) == "" {
+		s.ChannelMessageSend(m.ChannelID, "**[Muse]** Usage: `find <part of a title>`")
+		return
+	}
+
+	queueMutex.Lock()
+	applyFairOrderLocked() // Positions must match what `queue` shows
+	matches := findInQueueLocked(text)
+	queueMutex.Unlock()
+
+	if len(matches) == 0 {
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("**[Muse]** :mag: Nothing in the queue matches %q.", text))
+		return
+	}
+
+	s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("**[Muse]** :mag: %d songs in the queue match %q:\n%s", len(matches), text, formatQueueMatches(matches, findResultLimit)))
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Component IDs used on the queue message; the page to show is appended, e.g. "queue:page:3"
+const (
+	queueComponentPrefix = "queue:"
+	queuePageIDPrefix    = queueComponentPrefix + "page:"
+)
+
+const queuePageSize = 10 // Songs per queue page
+
+// queueETA is a queued song together with when it is expected to start
+type queueETA struct {
+	Track   Track
+	StartIn time.Duration // Time until the song starts, counting only known durations
+	Approx  bool          // Set once a song ahead of it has no known duration, so StartIn is a lower bound
+}
+
+// queueTimeline works out when each queued song starts, based on what's left of the current song
+// and the durations of the songs ahead of it. It also returns the total length of the queue and
+// how many songs have no known duration.
+func queueTimeline(songs []Track) ([]queueETA, time.Duration, int) {
+	var offset time.Duration
+	approx := false
+
+	if v.nowPlaying != (Track{}) {
+		if total := nowPlayingDuration(); total > 0 {
+			if remaining := total - v.elapsed(); remaining > 0 {
+				offset = remaining
+			}
+		} else {
+			approx = true
+		}
+	}
+
+	timeline := make([]queueETA, 0, len(songs))
+	var total time.Duration
+	unknown := 0
+	for _, song := range songs {
+		timeline = append(timeline, queueETA{Track: song, StartIn: offset, Approx: approx})
+
+		if song.Duration > 0 {
+			offset += song.Duration
+			total += song.Duration
+		} else {
+			approx = true
+			unknown++
+		}
+	}
+
+	return timeline, total, unknown
+}
+
+// formatETA renders the time until a song starts, e.g. "starts in 14m" or "starts in 1h5m+"
+func formatETA(entry queueETA) string {
+	suffix := ""
+	if entry.Approx {
+		suffix = "+"
+	}
+
+	d := entry.StartIn.Round(time.Minute)
+	switch {
+	case entry.StartIn < time.Minute && !entry.Approx:
+		return "starts in under a minute"
+	case d < time.Hour:
+		return fmt.Sprintf("starts in %dm%s", int(d.Minutes()), suffix)
+	default:
+		return fmt.Sprintf("starts in %dh%dm%s", int(d.Hours()), int(d.Minutes())%60, suffix)
+	}
+}
+
+// renderQueuePage builds the queue embed and its paging buttons for the given 0-based page
+func renderQueuePage(page int) (*discordgo.MessageEmbed, []discordgo.MessageComponent) {
+	queueMutex.Lock()
+	applyFairOrderLocked()
+	songs := make([]Track, len(queue))
+	copy(songs, queue)
+	queueMutex.Unlock()
+
+	embed := &discordgo.MessageEmbed{
+		Title: ":musical_note:   QUEUE LIST   :musical_note:",
+		Color: 0xFF0000,
+	}
+
+	var description strings.Builder
+	if v.nowPlaying != (Track{}) {
+		fmt.Fprintf(&description, "**Now Playing:** %s  ->  Queued by <@%s>\n\n", v.nowPlaying.Title, v.nowPlaying.RequestedBy)
+	} else {
+		description.WriteString("**Nothing is currently playing**\n\n")
+	}
+
+	if len(songs) == 0 {
+		description.WriteString("The queue is empty. :sleeping:\nUse `play [song/URL]` to add music to the queue!")
+		embed.Description = description.String()
+		return embed, []discordgo.MessageComponent{}
+	}
+
+	pages := (len(songs) + queuePageSize - 1) / queuePageSize
+	page = max(0, min(page, pages-1))
+
+	timeline, total, unknown := queueTimeline(songs)
+	start := page * queuePageSize
+	end := min(start+queuePageSize, len(timeline))
+
+	for index, entry := range timeline[start:end] {
+		length := ""
+		if entry.Track.Duration > 0 {
+			length = " `" + formatClock(entry.Track.Duration) + "`"
+		}
+		fmt.Fprintf(&description, "`%d.` %s%s\n     <@%s> • %s\n", start+index+1, truncateText(entry.Track.Title, 80), length, entry.Track.RequestedBy, formatETA(entry))
+	}
+	embed.Description = description.String()
+
+	totalText := formatDuration(total) + " total"
+	if unknown > 0 {
+		totalText += fmt.Sprintf(" (+%d of unknown length)", unknown)
+	}
+	embed.Footer = &discordgo.MessageEmbedFooter{
+		Text: fmt.Sprintf("Page %d/%d • %d songs • %s", page+1, pages, len(songs), totalText),
+	}
+	if settingsFor(v.guildID).FairQueue {
+		embed.Footer.Text += " • Fair queue: requesters take turns"
+	}
+
+	components := []discordgo.MessageComponent{
+		discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+			discordgo.Button{
+				Label:    "Previous",
+				Style:    discordgo.SecondaryButton,
+				CustomID: queuePageIDPrefix + strconv.Itoa(page-1),
+				Disabled: page == 0,
+				Emoji:    &discordgo.ComponentEmoji{Name: "◀️"},
+			},
+			discordgo.Button{
+				Label:    "Next",
+				Style:    discordgo.SecondaryButton,
+				CustomID: queuePageIDPrefix + strconv.Itoa(page+1),
+				Disabled: page+1 >= pages,
+				Emoji:    &discordgo.ComponentEmoji{Name: "▶️"},
+			},
+		}},
+	}
+
+	return embed, components
+}
+
+// handleQueueComponent pages through the queue message. Pages are rendered from the live queue,
+// so paging also picks up songs added or removed since the message was posted.
+func (app *Application) handleQueueComponent(s *discordgo.Session, i *discordgo.InteractionCreate, data discordgo.MessageComponentInteractionData) {
+	page, err := strconv.Atoi(strings.TrimPrefix(data.CustomID, queuePageIDPrefix))
+	if err != nil {
+		respondEphemeral(s, i, "**[Muse]** This control is no longer active.")
+		return
+	}
+
+	embed, components := renderQueuePage(page)
+	err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Embeds:     []*discordgo.MessageEmbed{embed},
+			Components: components,
+		},
+	})
+	if err != nil {
+		log.Printf("WARN: Failed to show queue page %d: %v", page+1, err)
+	}
+}
@@ -0,0 +1,252 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// quotaUsage is what one user currently has waiting in the queue
+type quotaUsage struct {
+	Tracks int
+	Length time.Duration // Known durations only
+}
+
+// admissionReport counts the tracks admitTracksLocked turned away, per limit
+type admissionReport struct {
+	TooLong     int // Longer than the maximum track length
+	OverCount   int // Over the number of tracks a user can have queued
+	OverMinutes int // Would push the user's queued time over the limit
+	Duplicates  int // Already queued or recently played, with the reject policy
+
+	DuplicatesAdded int            // Duplicates let through with the warn policy
+	Duplicate       duplicateMatch // The first duplicate found, either way
+}
+
+// Rejected returns how many tracks were turned away
+func (r admissionReport) Rejected() int {
+	return r.TooLong + r.OverCount + r.OverMinutes + r.Duplicates
+}
+
+// Warning notes duplicates that were queued anyway, or returns "" when there were none
+func (r admissionReport) Warning() string {
+	if r.DuplicatesAdded == 0 {
+		return ""
+	}
+	return fmt.Sprintf("**[Muse]** ⚠️ %d of these songs were already queued or played recently, added them anyway.", r.DuplicatesAdded)
+}
+
+// Describe names each limit that turned tracks away, e.g. for "Skipped 3 songs: ..."
+func (r admissionReport) Describe(settings GuildSettings) string {
+	var reasons []string
+	if r.TooLong > 0 {
+		reasons = append(reasons, fmt.Sprintf("%d longer than the %s song length limit", r.TooLong, formatMinutes(settings.MaxTrackMinutes)))
+	}
+	if r.OverCount > 0 {
+		reasons = append(reasons, fmt.Sprintf("%d over the limit of %d queued songs per person", r.OverCount, settings.MaxTracksPerUser))
+	}
+	if r.OverMinutes > 0 {
+		reasons = append(reasons, fmt.Sprintf("%d over the limit of %s queued per person", r.OverMinutes, formatMinutes(settings.MaxQueuedMinutes)))
+	}
+	if r.Duplicates > 0 {
+		reasons = append(reasons, fmt.Sprintf("%d already queued or played recently", r.Duplicates))
+	}
+	return strings.Join(reasons, ", ")
+}
+
+// userQueueUsageLocked returns what the user has waiting in the queue; queueMutex must be held
+func userQueueUsageLocked(userID string) quotaUsage {
+	usage := quotaUsage{Length: queuedLength(queue, userID)}
+	for _, track := range queue {
+		if track.RequestedBy == userID {
+			usage.Tracks++
+		}
+	}
+	return usage
+}
+
+// admitTracksLocked checks tracks a user wants to queue against the guild's per-user limits and
+// duplicate policy, and returns the ones that fit, in order. Tracks of unknown length only count
+// against the track limit. Without checkHistory only the queue and the current song count as
+// duplicates, for tracks taken from the history on purpose. queueMutex must be held until the
+// admitted tracks are queued, so two requests from the same user can't both fit under a limit.
+func admitTracksLocked(guildID, userID string, tracks []Track, checkHistory bool) ([]Track, admissionReport) {
+	settings := settingsFor(guildID)
+	maxLength := time.Duration(settings.MaxTrackMinutes) * time.Minute
+	maxQueued := time.Duration(settings.MaxQueuedMinutes) * time.Minute
+
+	usage := userQueueUsageLocked(userID)
+	queued := len(queue)
+	var duplicates *duplicateChecker
+	if settings.DuplicatePolicy == duplicatesWarn || settings.DuplicatePolicy == duplicatesReject {
+		window := time.Duration(settings.DuplicateWindowMinutes) * time.Minute
+		if !checkHistory {
+			window = 0
+		}
+		duplicates = newDuplicateCheckerLocked(guildID, window)
+	}
+
+	var report admissionReport
+	admitted := make([]Track, 0, len(tracks))
+	for _, track := range tracks {
+		switch {
+		case maxLength > 0 && track.Duration > maxLength:
+			report.TooLong++
+		case settings.MaxTracksPerUser > 0 && usage.Tracks >= settings.MaxTracksPerUser:
+			report.OverCount++
+		case maxQueued > 0 && usage.Length+track.Duration > maxQueued:
+			report.OverMinutes++
+		default:
+			if duplicates != nil {
+				if match, found := duplicates.Find(track); found {
+					if report.Duplicates+report.DuplicatesAdded == 0 {
+						report.Duplicate = match
+					}
+					if settings.DuplicatePolicy == duplicatesReject {
+						report.Duplicates++
+						continue
+					}
+					report.DuplicatesAdded++
+				}
+				duplicates.Admit(track, queued+len(admitted)+1)
+			}
+
+			admitted = append(admitted, track)
+			usage.Tracks++
+			usage.Length += track.Duration
+		}
+	}
+	return admitted, report
+}
+
+// admitTrack queues a single track at the spot the message asked for if it fits the per-user
+// limits, telling the user which limit it hit otherwise
+func admitTrack(m *discordgo.MessageCreate, track Track) bool {
+	return admitTrackChecked(m, track, true)
+}
+
+// admitHistoryTrack is admitTrack for a track picked from the history, which was played recently
+// by definition, so only the queue and the current song are checked for duplicates
+func admitHistoryTrack(m *discordgo.MessageCreate, track Track) bool {
+	return admitTrackChecked(m, track, false)
+}
+
+func admitTrackChecked(m *discordgo.MessageCreate, track Track, checkHistory bool) bool {
+	queueMutex.Lock()
+	admitted, report := admitTracksLocked(m.GuildID, m.Author.ID, []Track{track}, checkHistory)
+	interrupt := len(admitted) == 1 && enqueueTracksLocked(m, admitted...)
+	queueMutex.Unlock()
+	interruptForPlayNow(interrupt)
+
+	if len(admitted) == 1 {
+		if report.DuplicatesAdded > 0 {
+			s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("**[Muse]** ⚠️ [%s] looks like a duplicate: %s. Adding it anyway.", track.Title, report.Duplicate.Describe()))
+		}
+		return true
+	}
+
+	reason := rejectionReason(report, settingsFor(m.GuildID), track)
+	s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("**[Muse]** 🚫 Can't add [%s]: %s.", track.Title, reason))
+	return false
+}
+
+// rejectionReason explains which limit turned away a single track
+func rejectionReason(report admissionReport, settings GuildSettings, track Track) string {
+	switch {
+	case report.TooLong > 0:
+		return fmt.Sprintf("it's %s long and songs on this server can be at most %s", formatClock(track.Duration), formatMinutes(settings.MaxTrackMinutes))
+	case report.OverCount > 0:
+		return fmt.Sprintf("you already have %d songs queued, the limit per person on this server", settings.MaxTracksPerUser)
+	case report.Duplicates > 0:
+		return report.Duplicate.Describe() + ", and this server doesn't allow duplicates"
+	default:
+		return fmt.Sprintf("it would put you over %s of queued music, the limit per person on this server", formatMinutes(settings.MaxQueuedMinutes))
+	}
+}
+
+// rejectedTrack is a queued track taken out again by dropOverLengthLocked
+type rejectedTrack struct {
+	Track  Track
+	Report admissionReport
+}
+
+// dropOverLengthLocked applies the length limits to the filled queued tracks, whose duration was
+// just filled in. They were admitted while it was unknown, so only the track limit applied then.
+// A track longer than the maximum, or one that puts its requester over their queued time, is
+// removed from the queue and returned. queueMutex must be held.
+func dropOverLengthLocked(guildID string, filled map[trackKey]bool) []rejectedTrack {
+	settings := settingsFor(guildID)
+	maxLength := time.Duration(settings.MaxTrackMinutes) * time.Minute
+	maxQueued := time.Duration(settings.MaxQueuedMinutes) * time.Minute
+	if maxLength <= 0 && maxQueued <= 0 {
+		return nil
+	}
+
+	var dropped []rejectedTrack
+	kept := make([]Track, 0, len(queue))
+	for i, track := range queue {
+		var report admissionReport
+		if filled[keyOf(track)] {
+			switch {
+			case maxLength > 0 && track.Duration > maxLength:
+				report.TooLong++
+			case maxQueued > 0 && queuedLength(kept, track.RequestedBy)+queuedLength(queue[i:], track.RequestedBy) > maxQueued:
+				report.OverMinutes++
+			}
+		}
+		if report.Rejected() > 0 {
+			dropped = append(dropped, rejectedTrack{track, report})
+			continue
+		}
+		kept = append(kept, track)
+	}
+
+	if len(dropped) > 0 {
+		queue = kept
+	}
+	return dropped
+}
+
+// queuedLength adds up the known durations of the user's tracks
+func queuedLength(tracks []Track, userID string) time.Duration {
+	var length time.Duration
+	for _, track := range tracks {
+		if track.RequestedBy == userID {
+			length += track.Duration
+		}
+	}
+	return length
+}
+
+// checkUserTrackCap is a cheap check before any lookups: it tells the user when they already have
+// as many tracks queued as the guild allows. Length limits are checked once durations are known.
+func checkUserTrackCap(m *discordgo.MessageCreate) bool {
+	limit := settingsFor(m.GuildID).MaxTracksPerUser
+	if limit <= 0 {
+		return true
+	}
+
+	queueMutex.Lock()
+	usage := userQueueUsageLocked(m.Author.ID)
+	queueMutex.Unlock()
+
+	if usage.Tracks >= limit {
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("**[Muse]** 🚫 You already have %d songs queued, the limit per person on this server. Wait for some of them to play first.", limit))
+		return false
+	}
+	return true
+}
+
+// formatMinutes renders a limit given in minutes, e.g. "45m", "2h" or "1h30m"
+func formatMinutes(minutes int) string {
+	switch {
+	case minutes < 60:
+		return fmt.Sprintf("%dm", minutes)
+	case minutes%60 == 0:
+		return fmt.Sprintf("%dh", minutes/60)
+	default:
+		return fmt.Sprintf("%dh%dm", minutes/60, minutes%60)
+	}
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const replayHistoryLimit = 20 // Entries shown by `history`, which `replay <n>` picks from
+
+// How far `back` has gone through each guild's history, so repeating it keeps going further back.
+// The cursor stays while songs picked by `back` play and starts over when any other song starts.
+var (
+	backSteps      = make(map[string]int)    // History entries stepped back through, by guild ID
+	backMessages   = make(map[string]string) // Message ID of the last `back`, which its song carries
+	backStepsMutex sync.Mutex
+)
+
+// backStep returns how many history entries the guild's `back` has already stepped through
+func backStep(guildID string) int {
+	backStepsMutex.Lock()
+	defer backStepsMutex.Unlock()
+	return backSteps[guildID]
+}
+
+// steppedBack moves the guild's cursor past the entry the given `back` message picked
+func steppedBack(guildID, messageID string, step int) {
+	backStepsMutex.Lock()
+	backSteps[guildID] = step
+	backMessages[guildID] = messageID
+	backStepsMutex.Unlock()
+}
+
+// resetBackSteps starts the guild's cursor over when a song the last `back` didn't pick starts
+func resetBackSteps(guildID string, track Track) {
+	backStepsMutex.Lock()
+	if track.MessageID != backMessages[guildID] {
+		delete(backSteps, guildID)
+		delete(backMessages, guildID)
+	}
+	backStepsMutex.Unlock()
+}
+
+// replayTrack turns a history entry into a fresh queue entry requested by the message author,
+// playing from the cached file when the song is still in the download cache
+func replayTrack(m *discordgo.MessageCreate, entry HistoryEntry) Track {
+	track := entry.Track
+	track.RequestedBy = m.Author.ID
+	track.RequestedAt = time.Now()
+	track.ChannelID = m.ChannelID
+	track.MessageID = m.ID
+	track.startAt(0)
+	track.PlayedSoFar = 0
+	track.StreamURL = "" // Never saved, stream URLs expire
+
+	if track.Source != SourceYouTube {
+		return track
+	}
+	if metadataManager != nil && metadataManager.HasSong(track.VideoID) {
+		if cached, ok := metadataManager.GetSong(track.VideoID); ok {
+			track.FilePath = cached.FilePath
+			return track
+		}
+	}
+	if track.FilePath != "" {
+		if _, err := os.Stat(track.FilePath); err != nil {
+			track.FilePath = "" // Evicted from the cache, yt-dlp downloads it again from the watch URL
+		}
+	}
+	return track
+}
+
+// startPlaybackIfIdle joins voice and starts the queue when nothing is playing
+func startPlaybackIfIdle(m *discordgo.MessageCreate) {
+	if v.nowPlaying != (Track{}) || getPlaybackState() {
+		return
+	}
+
+	v.currentUserID = m.Author.ID
+	if err := joinVoiceChannelWithError(); err != nil {
+		voiceErr := NewVoiceError("Failed to join voice channel",
+			"Could not join voice channel. Please check permissions.", err).
+			WithContext("guild_id", v.guildID).
+			WithContext("user_id", m.Author.ID)
+		errorHandler.Handle(voiceErr, m.ChannelID)
+		return
+	}
+	prepFirstSongEntered(m, false)
+}
+
+// backCommand plays the previously played song again right away; the current song resumes afterwards.
+// Repeating it goes further back through the history.
+func backCommand(s *discordgo.Session, m *discordgo.MessageCreate) {
+	if historyManager == nil {
+		s.ChannelMessageSend(m.ChannelID, "**[Muse]** ❌ History system is not available.")
+		return
+	}
+	if settingsFor(m.GuildID).FairQueue {
+		s.ChannelMessageSend(m.ChannelID, "**[Muse]** The fair queue decides the order on this server, songs can't skip ahead. Use `replay 1` to queue the previous song instead.")
+		return
+	}
+	if !requireSameVoiceChannel(m) {
+		return
+	}
+
+	step := backStep(m.GuildID)
+	entries, err := historyManager.GetHistory(m.GuildID, step+1)
+	if err != nil {
+		log.Printf("ERROR: Failed to get history: %v", err)
+		s.ChannelMessageSend(m.ChannelID, "**[Muse]** ❌ Failed to retrieve history.")
+		return
+	}
+	if len(entries) <= step {
+		if step > 0 {
+			s.ChannelMessageSend(m.ChannelID, "**[Muse]** 📜 That's as far back as the history goes.")
+			return
+		}
+		s.ChannelMessageSend(m.ChannelID, "**[Muse]** 📜 Nothing was played before this, there's no previous song.")
+		return
+	}
+
+	track := replayTrack(m, entries[step])
+	setPlacement(m.ID, placeNow)
+	defer clearPlacement(m.ID)
+	if !admitHistoryTrack(m, track) {
+		return
+	}
+	// The song may have started already, which reset the cursor; this sets it either way
+	steppedBack(m.GuildID, m.ID, step+1)
+
+	s.ChannelMessageSend(m.ChannelID, "**[Muse]** :rewind: Going back to ["+track.Title+"]"+placementNote(m.ID))
+	startPlaybackIfIdle(m)
+}
+
+// replayCommand queues entry n of the `history` listing again
+func replayCommand(s *discordgo.Session, m *discordgo.MessageCreate, args []string) {
+	if historyManager == nil {
+		s.ChannelMessageSend(m.ChannelID, "**[Muse]** ❌ History system is not available.")
+		return
+	}
+
+	if len(args) != 1 {
+		s.ChannelMessageSend(m.ChannelID, "**[Muse]** Usage: `replay <number>`, with the number from `history`")
+		return
+	}
+	index, err := strconv.Atoi(args[0])
+	if err != nil {
+		s.ChannelMessageSend(m.ChannelID, "**[Muse]** Usage: `replay <number>`, with the number from `history`")
+		return
+	}
+
+	entries, err := historyManager.GetHistory(m.GuildID, replayHistoryLimit)
+	if err != nil {
+		log.Printf("ERROR: Failed to get history: %v", err)
+		s.ChannelMessageSend(m.ChannelID, "**[Muse]** ❌ Failed to retrieve history.")
+		return
+	}
+	if index < 1 || index > len(entries) {
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("**[Muse]** Pick a number between 1 and %d from `history`.", len(entries)))
+		return
+	}
+
+	if !requireSameVoiceChannel(m) {
+		return
+	}
+
+	queueMutex.Lock()
+	full := len(queue) >= maxQueueSize
+	queueMutex.Unlock()
+	if full {
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("**[Muse]** 🚫 Queue is full! Maximum size is %d songs. Please wait for some songs to finish.", maxQueueSize))
+		return
+	}
+
+	track := replayTrack(m, entries[index-1])
+	if !admitHistoryTrack(m, track) {
+		return
+	}
+
+	cached := ""
+	if track.FilePath != "" {
+		cached = "cached "
+	}
+	s.ChannelMessageSend(m.ChannelID, "**[Muse]** :repeat: Adding "+cached+"["+track.Title+"] from history to the Queue  :musical_note:")
+	startPlaybackIfIdle(m)
+}
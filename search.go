@@ -0,0 +1,279 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Component IDs used on search result messages
+const (
+	searchComponentPrefix = "search:"
+	searchSelectID        = searchComponentPrefix + "select"
+	searchNextID          = searchComponentPrefix + "next"
+	searchCancelID        = searchComponentPrefix + "cancel"
+)
+
+const (
+	searchSessionTTL = 10 * time.Minute // Results can be picked from for this long after the last page was shown
+	maxSearchPages   = 5                // "Next page" stops after this many pages
+)
+
+// searchSession is the result list shown to one user; only that user can pick from it
+type searchSession struct {
+	UserID        string
+	UserName      string
+	GuildID       string
+	ChannelID     string
+	MessageID     string // Message holding the results and their components
+	Query         string
+	Options       SearchOptions // Filters applied to every page
+	Page          int           // 0-based page currently shown
+	NextPageToken string        // API token for the next page, "" when the page came from yt-dlp
+	HasMore       bool          // Whether another page can be fetched
+	Results       []SongSearch  // Results on the current page, in the order shown
+	UpdatedAt     time.Time
+}
+
+var (
+	searchSessions      = make(map[string]searchSession) // Active searches by guild+user
+	searchSessionsMutex sync.Mutex
+)
+
+// searchSessionKey scopes searches per user within a guild
+func searchSessionKey(guildID, userID string) string {
+	return guildID + ":" + userID
+}
+
+// storeSearchSession saves the user's search, replacing any earlier one
+func storeSearchSession(session *searchSession) {
+	session.UpdatedAt = time.Now()
+	searchSessionsMutex.Lock()
+	searchSessions[searchSessionKey(session.GuildID, session.UserID)] = *session
+	searchSessionsMutex.Unlock()
+}
+
+// getSearchSession returns a copy of the user's active search, or nil if there is none or it expired
+func getSearchSession(guildID, userID string) *searchSession {
+	searchSessionsMutex.Lock()
+	defer searchSessionsMutex.Unlock()
+
+	key := searchSessionKey(guildID, userID)
+	session, ok := searchSessions[key]
+	if !ok {
+		return nil
+	}
+	if time.Since(session.UpdatedAt) > searchSessionTTL {
+		delete(searchSessions, key)
+		return nil
+	}
+	return &session
+}
+
+// findSearchSessionByMessage returns the search shown in the given message, or nil
+func findSearchSessionByMessage(messageID string) *searchSession {
+	searchSessionsMutex.Lock()
+	defer searchSessionsMutex.Unlock()
+
+	for _, session := range searchSessions {
+		if session.MessageID == messageID {
+			return &session
+		}
+	}
+	return nil
+}
+
+// hasActiveSearch reports whether the user has search results waiting for a pick
+func hasActiveSearch(guildID, userID string) bool {
+	return getSearchSession(guildID, userID) != nil
+}
+
+// Clears the user's search results
+func resetSearch(guildID, userID string) {
+	searchSessionsMutex.Lock()
+	delete(searchSessions, searchSessionKey(guildID, userID))
+	searchSessionsMutex.Unlock()
+}
+
+// renderSearchResults builds the results embed and the select menu / buttons for a search page
+func renderSearchResults(session *searchSession) (*discordgo.MessageEmbed, []discordgo.MessageComponent) {
+	var description strings.Builder
+	options := make([]discordgo.SelectMenuOption, 0, len(session.Results))
+
+	for i, result := range session.Results {
+		details := resultDetails(result)
+
+		// Check if this song is cached
+		cachedIndicator := ""
+		if metadataManager != nil && metadataManager.HasSong(result.Id) {
+			cachedIndicator = " :recycle:"
+		}
+
+		fmt.Fprintf(&description, "`%d.` **%s**%s\n", i+1, result.Name, cachedIndicator)
+		if details != "" {
+			fmt.Fprintf(&description, "     %s\n", details)
+		}
+
+		options = append(options, discordgo.SelectMenuOption{
+			Label:       truncateText(fmt.Sprintf("%d. %s", i+1, result.Name), 100),
+			Value:       strconv.Itoa(i + 1),
+			Description: truncateText(details, 100),
+		})
+	}
+
+	title := ":musical_note: Search results for \"" + truncateText(session.Query, 200) + "\""
+	if session.Options.HasFilters() {
+		title += " (" + session.Options.Describe() + ")"
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       title,
+		Description: description.String(),
+		Color:       0xFF0000,
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: fmt.Sprintf("Page %d • Only %s can pick • Or type play <number>", session.Page+1, session.UserName),
+		},
+	}
+
+	hasNextPage := session.HasMore && session.Page+1 < maxSearchPages
+
+	components := []discordgo.MessageComponent{
+		discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+			discordgo.SelectMenu{
+				MenuType:    discordgo.StringSelectMenu,
+				CustomID:    searchSelectID,
+				Placeholder: "Pick a song to queue",
+				Options:     options,
+			},
+		}},
+		discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+			discordgo.Button{
+				Label:    "Next page",
+				Style:    discordgo.SecondaryButton,
+				CustomID: searchNextID,
+				Disabled: !hasNextPage,
+				Emoji:    &discordgo.ComponentEmoji{Name: "▶️"},
+			},
+			discordgo.Button{
+				Label:    "Cancel",
+				Style:    discordgo.DangerButton,
+				CustomID: searchCancelID,
+			},
+		}},
+	}
+
+	return embed, components
+}
+
+// resultDetails returns the "channel • duration" line for a search result
+func resultDetails(result SongSearch) string {
+	var parts []string
+	if result.Channel != "" {
+		parts = append(parts, result.Channel)
+	}
+	if result.Duration != "" {
+		parts = append(parts, result.Duration)
+	}
+	return strings.Join(parts, " • ")
+}
+
+// truncateText shortens text to at most limit characters, marking the cut with an ellipsis
+func truncateText(text string, limit int) string {
+	runes := []rune(text)
+	if len(runes) <= limit {
+		return text
+	}
+	return string(runes[:limit-1]) + "…"
+}
+
+// closeSearchMessage replaces the results message with a short note and removes its components
+func closeSearchMessage(session *searchSession, note string) {
+	if session.MessageID == "" {
+		return
+	}
+
+	embeds := []*discordgo.MessageEmbed{{
+		Title:       ":musical_note: Search results for \"" + truncateText(session.Query, 200) + "\"",
+		Description: note,
+		Color:       0x808080,
+	}}
+	components := []discordgo.MessageComponent{}
+	_, err := s.ChannelMessageEditComplex(&discordgo.MessageEdit{
+		ID:         session.MessageID,
+		Channel:    session.ChannelID,
+		Embeds:     &embeds,
+		Components: &components,
+	})
+	if err != nil {
+		log.Printf("WARN: Failed to close search message %s: %v", session.MessageID, err)
+	}
+}
+
+// handleSearchComponent handles the select menu and buttons on a search results message
+func (app *Application) handleSearchComponent(s *discordgo.Session, i *discordgo.InteractionCreate, data discordgo.MessageComponentInteractionData) {
+	user := interactionUser(i)
+	session := findSearchSessionByMessage(i.Message.ID)
+	if session == nil || time.Since(session.UpdatedAt) > searchSessionTTL {
+		respondEphemeral(s, i, "**[Muse]** These search results have expired. Search again with `play <search terms>`.")
+		return
+	}
+	if user == nil || session.UserID != user.ID {
+		respondEphemeral(s, i, fmt.Sprintf("**[Muse]** These results belong to %s. Search with `play <search terms>` to get your own.", session.UserName))
+		return
+	}
+
+	acknowledgeComponent(s, i)
+
+	switch data.CustomID {
+	case searchSelectID:
+		if len(data.Values) == 0 {
+			return
+		}
+		// Run the pick through the text command path so it gets the same checks as `play <number>`
+		if err := app.processCommand(s, componentMessage(i, "play "+data.Values[0])); err != nil {
+			log.Printf("ERROR: Failed to queue search selection: %v", err)
+		}
+
+	case searchNextID:
+		go func() {
+			defer RecoverWithErrorHandler(errorHandler, session.ChannelID)
+			showNextSearchPage(session)
+		}()
+
+	case searchCancelID:
+		resetSearch(session.GuildID, session.UserID)
+		closeSearchMessage(session, "Search cancelled.")
+	}
+}
+
+// showNextSearchPage fetches the next page of results and updates the results message in place
+func showNextSearchPage(session *searchSession) {
+	result, page := searchUntilResults(session.Query, session.Options, session.Page+1, session.NextPageToken)
+	if len(result.Results) == 0 {
+		s.ChannelMessageSend(session.ChannelID, "**[Muse]** No more search results.")
+		return
+	}
+
+	session.Page = page
+	session.Results = result.Results
+	session.NextPageToken = result.NextPageToken
+	session.HasMore = result.HasMore
+	storeSearchSession(session)
+
+	embed, components := renderSearchResults(session)
+	embeds := []*discordgo.MessageEmbed{embed}
+	_, err := s.ChannelMessageEditComplex(&discordgo.MessageEdit{
+		ID:         session.MessageID,
+		Channel:    session.ChannelID,
+		Embeds:     &embeds,
+		Components: &components,
+	})
+	if err != nil {
+		log.Printf("ERROR: Failed to show search page %d: %v", session.Page+1, err)
+	}
+}
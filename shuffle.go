@@ -0,0 +1,234 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"math/rand"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Shuffle algorithms, selected with `shuffle random|smart` or QueueConfig.ShuffleAlgorithm
+const (
+	shuffleFisherYates = "fisher-yates" // Uniformly random order
+	shuffleSmart       = "smart"        // Random, but songs from the same requester or artist are spread apart
+)
+
+// queueShuffler shuffles with its own seeded RNG, so a shuffle can be reproduced from the logged seed
+type queueShuffler struct {
+	mutex     sync.Mutex
+	rng       *rand.Rand
+	seed      int64
+	algorithm string // Used by a plain `shuffle`
+}
+
+// Global shuffler (reconfigured from the config in main.go)
+var shuffler = newQueueShuffler(0, shuffleFisherYates)
+
+// unshuffledQueue is the queue order before the last shuffle, nil when there is nothing to undo.
+// It is guarded by queueMutex like the queue itself, and reset wherever the queue is emptied.
+var unshuffledQueue []Track
+
+// newQueueShuffler creates a shuffler; a zero seed is taken from the clock
+func newQueueShuffler(seed int64, algorithm string) *queueShuffler {
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	if algorithm == "" {
+		algorithm = shuffleFisherYates
+	}
+	return &queueShuffler{
+		rng:       rand.New(rand.NewSource(seed)),
+		seed:      seed,
+		algorithm: algorithm,
+	}
+}
+
+// Swap randomly permutes n elements with a Fisher–Yates shuffle, calling swap for each exchange
+func (qs *queueShuffler) Swap(n int, swap func(i, j int)) {
+	qs.mutex.Lock()
+	defer qs.mutex.Unlock()
+
+	for i := n - 1; i > 0; i-- {
+		j := qs.rng.Intn(i + 1)
+		swap(i, j)
+	}
+}
+
+// Shuffle returns the tracks in random order using the given algorithm, leaving tracks untouched
+func (qs *queueShuffler) Shuffle(tracks []Track, algorithm string) []Track {
+	shuffled := make([]Track, len(tracks))
+	copy(shuffled, tracks)
+	qs.Swap(len(shuffled), func(i, j int) {
+		shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
+	})
+
+	if algorithm == shuffleSmart {
+		return spreadApart(shuffled)
+	}
+	return shuffled
+}
+
+// spreadApart reorders already shuffled tracks so that, where possible, no two neighbours share a
+// requester or an artist. Each step takes the track that clashes least with the previous one,
+// preferring whoever has the most tracks left so they don't pile up at the end; remaining ties
+// keep the shuffled order, so the result stays random.
+func spreadApart(tracks []Track) []Track {
+	remaining := make([]Track, len(tracks))
+	copy(remaining, tracks)
+
+	left := make(map[string]int)
+	artists := make(map[Track]string, len(tracks))
+	for _, track := range tracks {
+		left[track.RequestedBy]++
+		artists[track] = trackArtist(track)
+	}
+
+	ordered := make([]Track, 0, len(tracks))
+	for len(remaining) > 0 {
+		best, bestClash := 0, -1
+		for i, track := range remaining {
+			clash := 0
+			if len(ordered) > 0 {
+				previous := ordered[len(ordered)-1]
+				if track.RequestedBy == previous.RequestedBy {
+					clash++
+				}
+				if artist := artists[track]; artist != "" && artist == artists[previous] {
+					clash++
+				}
+			}
+
+			if bestClash < 0 || clash < bestClash ||
+				clash == bestClash && left[track.RequestedBy] > left[remaining[best].RequestedBy] {
+				best, bestClash = i, clash
+			}
+		}
+
+		track := remaining[best]
+		ordered = append(ordered, track)
+		left[track.RequestedBy]--
+		remaining = append(remaining[:best], remaining[best+1:]...)
+	}
+	return ordered
+}
+
+// trackArtist guesses a track's artist: "Artist - Title" style titles first, then the uploader
+func trackArtist(track Track) string {
+	if artist := extractArtistFromTitle(track.Title); artist != "" {
+		return strings.ToLower(artist)
+	}
+	return strings.ToLower(strings.TrimSuffix(track.Uploader, " - Topic"))
+}
+
+// trackKey identifies a queued track across edits to its details (durations are filled in later)
+type trackKey struct {
+	VideoID     string
+	FilePath    string
+	MessageID   string
+	RequestedAt time.Time
+}
+
+func keyOf(track Track) trackKey {
+	return trackKey{track.VideoID, track.FilePath, track.MessageID, track.RequestedAt}
+}
+
+// restoreOrder puts the current queue back in the original order. Tracks that were played or
+// removed since are left out, and tracks queued since keep their order at the end. It also
+// returns how many tracks were found in the original order.
+func restoreOrder(original, current []Track) ([]Track, int) {
+	waiting := make(map[trackKey][]Track)
+	for _, track := range current {
+		waiting[keyOf(track)] = append(waiting[keyOf(track)], track)
+	}
+
+	restored := make([]Track, 0, len(current))
+	for _, track := range original {
+		key := keyOf(track)
+		if tracks := waiting[key]; len(tracks) > 0 {
+			restored = append(restored, tracks[0])
+			waiting[key] = tracks[1:]
+		}
+	}
+	found := len(restored)
+
+	// Tracks queued after the shuffle
+	for _, track := range current {
+		key := keyOf(track)
+		if tracks := waiting[key]; len(tracks) > 0 {
+			restored = append(restored, tracks[0])
+			waiting[key] = tracks[1:]
+		}
+	}
+	return restored, found
+}
+
+// shuffleQueueCommand shuffles the queue: `shuffle`, `shuffle random` or `shuffle smart`
+func shuffleQueueCommand(s *discordgo.Session, m *discordgo.MessageCreate, args []string) {
+	algorithm := shuffler.algorithm
+	if len(args) > 0 {
+		switch strings.ToLower(args[0]) {
+		case "random", shuffleFisherYates:
+			algorithm = shuffleFisherYates
+		case shuffleSmart:
+			algorithm = shuffleSmart
+		default:
+			s.ChannelMessageSend(m.ChannelID, "**[Muse]** Usage: `shuffle` (random order), `shuffle smart` (spread out requesters and artists) or `unshuffle`")
+			return
+		}
+	}
+
+	queueMutex.Lock()
+	defer queueMutex.Unlock()
+
+	if len(queue) <= 1 {
+		s.ChannelMessageSend(m.ChannelID, "**[Muse]** :twisted_rightwards_arrows: Queue needs at least 2 songs to shuffle")
+		return
+	}
+
+	// Keep the first order seen, so unshuffle after several shuffles goes back to the original
+	if unshuffledQueue == nil {
+		unshuffledQueue = make([]Track, len(queue))
+		copy(unshuffledQueue, queue)
+	}
+	recordQueueEditLocked("shuffled the queue", nil)
+	queue = shuffler.Shuffle(queue, algorithm)
+	applyFairOrderLocked()
+	log.Printf("INFO: Shuffled %d songs (%s, seed %d)", len(queue), algorithm, shuffler.seed)
+
+	message := fmt.Sprintf("**[Muse]** :twisted_rightwards_arrows: Shuffled %d songs in the queue!", len(queue))
+	if algorithm == shuffleSmart {
+		message = fmt.Sprintf("**[Muse]** :twisted_rightwards_arrows: Smart shuffled %d songs, same requesters and artists are spread apart!", len(queue))
+	}
+	s.ChannelMessageSend(m.ChannelID, message+" Use `unshuffle` to undo.")
+	refreshUpNextLocked()
+}
+
+// unshuffleQueueCommand restores the queue order from before the first shuffle
+func unshuffleQueueCommand(s *discordgo.Session, m *discordgo.MessageCreate) {
+	queueMutex.Lock()
+	defer queueMutex.Unlock()
+
+	restored, found := restoreOrder(unshuffledQueue, queue)
+	unshuffledQueue = nil
+	if found == 0 {
+		s.ChannelMessageSend(m.ChannelID, "**[Muse]** There's no shuffle to undo.")
+		return
+	}
+
+	recordQueueEditLocked("unshuffled the queue", nil)
+	queue = restored
+	applyFairOrderLocked()
+
+	s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("**[Muse]** :arrow_right: Restored the original order of %d songs.", found))
+	refreshUpNextLocked()
+}
+
+// refreshUpNextLocked updates the card's "Up next" after the queue order changed. Rendering the
+// card takes queueMutex, which the caller holds, so it runs in the background.
+func refreshUpNextLocked() {
+	go refreshNowPlayingCard()
+}
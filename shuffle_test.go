@@ -0,0 +1,88 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// queuedTracks builds tracks from "requester:artist" pairs, one message each
+func queuedTracks(specs ...string) []Track {
+	tracks := make([]Track, len(specs))
+	for i, spec := range specs {
+		requester, artist, _ := strings.Cut(spec, ":")
+		tracks[i] = Track{
+			VideoID:     spec,
+			Title:       artist + " - Song",
+			RequestedBy: requester,
+			MessageID:   string(rune('a' + i)),
+			RequestedAt: time.Unix(int64(i), 0),
+		}
+	}
+	return tracks
+}
+
+func messageIDs(tracks []Track) string {
+	var ids strings.Builder
+	for _, track := range tracks {
+		ids.WriteString(track.MessageID)
+	}
+	return ids.String()
+}
+
+func TestSpreadApart(t *testing.T) {
+	tests := []struct {
+		name   string
+		tracks []Track
+		want   string
+	}{
+		{"empty", nil, ""},
+		{"one", queuedTracks("u1:Abba"), "a"},
+		{"requesters", queuedTracks("u1:Abba", "u1:Blur", "u2:Cher"), "acb"},
+		{"artists", queuedTracks("u1:Abba", "u2:Abba", "u3:Blur"), "acb"},
+		{"busiest requester first", queuedTracks("u1:Abba", "u2:Blur", "u2:Cher", "u2:Dido"), "bacd"},
+		{"no way apart", queuedTracks("u1:Abba", "u1:Abba"), "ab"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := messageIDs(spreadApart(tt.tracks)); got != tt.want {
+				t.Errorf("spreadApart() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRestoreOrder(t *testing.T) {
+	tracks := queuedTracks("u1:Abba", "u1:Blur", "u2:Cher", "u2:Dido", "u3:Enya")
+	a, b, c, d, e := tracks[0], tracks[1], tracks[2], tracks[3], tracks[4]
+	filled := b
+	filled.Duration = 3 * time.Minute
+
+	tests := []struct {
+		name     string
+		original []Track
+		current  []Track
+		want     string
+		found    int
+	}{
+		{"nothing shuffled", nil, []Track{b, a}, "ba", 0},
+		{"reversed", []Track{a, b, c}, []Track{c, b, a}, "abc", 3},
+		{"played since", []Track{a, b, c}, []Track{c, b}, "bc", 2},
+		{"queued since", []Track{a, b}, []Track{e, b, d, a}, "abed", 2},
+		{"duration filled in", []Track{a, b}, []Track{filled, a}, "ab", 2},
+		{"queued twice", []Track{a, c, a}, []Track{a, a, c}, "aca", 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, found := restoreOrder(tt.original, tt.current)
+			if messageIDs(got) != tt.want || found != tt.found {
+				t.Errorf("restoreOrder() = %q, %d, want %q, %d", messageIDs(got), found, tt.want, tt.found)
+			}
+		})
+	}
+	if got, _ := restoreOrder([]Track{a, b}, []Track{filled, a}); got[1].Duration != filled.Duration {
+		t.Errorf("restoreOrder() lost the filled in duration")
+	}
+}
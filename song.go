@@ -1,17 +1,183 @@
 package main
 
-// Fill a song struct - Used for the song queue
-func fillSongInfo(channelID string, authorID string, Id string, title string, videoID string, duration string) (songData Song) {
-	// Fill Song Info
-	song = Song{
-		ChannelID: channelID,
-		User:      authorID,
-		ID:        Id,
-		VidID:     videoID,
-		Title:     title,
-		Duration:  duration,
-		VideoURL:  "",
-	}
-
-	return song
+import (
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// TrackSource says where a track's audio comes from
+type TrackSource string
+
+const (
+	SourceYouTube TrackSource = "youtube" // YouTube video, streamed or downloaded by video ID
+	SourceLocal   TrackSource = "local"   // Audio file from the mpegs/ directory
+)
+
+// Track is a song in the queue, the now playing slot or the history.
+// It must stay comparable (no slices or maps), playback checks `v.nowPlaying == (Track{})`.
+type Track struct {
+	Source      TrackSource   `json:"source"`
+	VideoID     string        `json:"video_id,omitempty"` // YouTube video ID, empty for local files
+	Title       string        `json:"title"`
+	URL         string        `json:"url,omitempty"`       // Canonical page URL, e.g. the YouTube watch URL
+	StreamURL   string        `json:"-"`                   // Direct media URL resolved when queued; it expires, so it isn't saved
+	FilePath    string        `json:"file_path,omitempty"` // Local audio file, from the download cache or mpegs/
+	Duration    time.Duration `json:"duration"`            // Zero when unknown (live streams, missing metadata)
+	Thumbnail   string        `json:"thumbnail,omitempty"`
+	Uploader    string        `json:"uploader,omitempty"`
+	RequestedBy string        `json:"requested_by"` // Discord user ID of whoever queued it
+	RequestedAt time.Time     `json:"requested_at"`
+	ChannelID   string        `json:"channel_id"`           // Text channel the request came from
+	MessageID   string        `json:"message_id"`           // Discord message that queued it
+	StartTime   time.Duration `json:"start_time,omitempty"` // Offset to begin playback from (from t= / start= in the link)
+	LinkStart   time.Duration `json:"-"`                    // The link's own offset; StartTime moves on when a cut off track resumes
+	PlayedSoFar time.Duration `json:"-"`                    // Play time before the track was cut off, history counts it once the track resumes
+}
+
+// newYouTubeTrack builds a queue entry for a YouTube video requested by the message author
+func newYouTubeTrack(m *discordgo.MessageCreate, videoID, title string, duration time.Duration) Track {
+	return Track{
+		Source:      SourceYouTube,
+		VideoID:     videoID,
+		Title:       title,
+		URL:         youtubeWatchURL(videoID),
+		Duration:    duration,
+		Thumbnail:   youtubeThumbnail(videoID),
+		RequestedBy: m.Author.ID,
+		RequestedAt: time.Now(),
+		ChannelID:   m.ChannelID,
+		MessageID:   m.ID,
+	}
+}
+
+// newLocalTrack builds a queue entry for a file in the mpegs/ directory
+func newLocalTrack(m *discordgo.MessageCreate, fileName string) Track {
+	return Track{
+		Source:      SourceLocal,
+		Title:       strings.TrimSuffix(fileName, filepath.Ext(fileName)),
+		FilePath:    filepath.Join("mpegs", fileName),
+		RequestedBy: m.Author.ID,
+		RequestedAt: time.Now(),
+		ChannelID:   m.ChannelID,
+		MessageID:   m.ID,
+	}
+}
+
+// startAt sets the offset from the link the track was requested with
+func (t *Track) startAt(offset time.Duration) {
+	t.StartTime, t.LinkStart = offset, offset
+}
+
+// resumedAt returns the track set to pick up again at position after being cut off, having played for played
+func (t Track) resumedAt(position, played time.Duration) Track {
+	t.StartTime = position
+	t.PlayedSoFar += played
+	return t
+}
+
+// playbackPath returns what the player should open: the local file when there is one,
+// otherwise the resolved stream, otherwise the page URL for yt-dlp to download
+func (t Track) playbackPath() string {
+	switch {
+	case t.FilePath != "":
+		return t.FilePath
+	case t.StreamURL != "":
+		return t.StreamURL
+	default:
+		return t.URL
+	}
+}
+
+// youtubeWatchURL returns the canonical watch URL for a video ID
+func youtubeWatchURL(videoID string) string {
+	return "https://www.youtube.com/watch?v=" + videoID
+}
+
+// youtubeThumbnail returns the thumbnail for a video ID
+func youtubeThumbnail(videoID string) string {
+	return "https://i.ytimg.com/vi/" + videoID + "/hqdefault.jpg"
+}
+
+// parseSongDuration turns the free-form duration strings the sources report into a duration:
+// yt-dlp's duration_string ("4:05", "1:02:03"), plain seconds ("245") and Go duration strings ("4m5s").
+// It returns false when the duration is empty or unknown (live streams, missing metadata).
+func parseSongDuration(value string) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" || value == "NA" {
+		return 0, false
+	}
+
+	var d time.Duration
+	var err error
+	if strings.Contains(value, ":") {
+		d, err = parseClockDuration(value)
+	} else if seconds, convErr := strconv.ParseFloat(value, 64); convErr == nil {
+		d = time.Duration(seconds * float64(time.Second))
+	} else {
+		d, err = time.ParseDuration(value)
+	}
+
+	if err != nil || d <= 0 {
+		return 0, false
+	}
+	return d, true
+}
+
+// songDuration is parseSongDuration for callers that treat unknown as zero
+func songDuration(value string) time.Duration {
+	d, _ := parseSongDuration(value)
+	return d
+}
+
+// legacySong is the untagged Song struct that history.json stored before tracks were typed.
+// Duration was free-form text and VideoURL held a stream URL, a watch URL or a downloads/ path.
+type legacySong struct {
+	ChannelID string
+	User      string
+	ID        string
+	VidID     string
+	Title     string
+	Duration  string
+	VideoURL  string
+	StartTime time.Duration
+}
+
+// track converts a legacy song into a track
+func (ls legacySong) track() Track {
+	track := Track{
+		Source:      SourceYouTube,
+		VideoID:     ls.VidID,
+		Title:       ls.Title,
+		Duration:    songDuration(ls.Duration),
+		RequestedBy: ls.User,
+		ChannelID:   ls.ChannelID,
+		MessageID:   ls.ID,
+	}
+	track.startAt(ls.StartTime)
+
+	if ls.VidID != "" {
+		track.URL = youtubeWatchURL(ls.VidID)
+		track.Thumbnail = youtubeThumbnail(ls.VidID)
+	}
+
+	// Stream URLs have long expired, only keep file paths
+	if strings.HasPrefix(ls.VideoURL, "downloads/") || strings.HasPrefix(ls.VideoURL, "./downloads/") {
+		track.FilePath = ls.VideoURL
+	}
+
+	// Manual entries were queued by file name with the placeholder title "manual entry"
+	if ls.Title == "manual entry" {
+		track.Source = SourceLocal
+		track.Title = ls.VidID
+		track.VideoID = ""
+		track.URL = ""
+		track.Thumbnail = ""
+		track.FilePath = filepath.Join("mpegs", ls.VidID)
+	}
+
+	return track
 }
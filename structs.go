@@ -4,7 +4,6 @@ import (
 	"time"
 
 	"github.com/bwmarrin/discordgo"
-	"github.com/jonas747/dca"
 )
 
 type Options struct {
@@ -16,19 +15,12 @@ type Options struct {
 	YoutubeToken      string
 }
 
-type Song struct {
-	ChannelID string
-	User      string
-	ID        string
-	VidID     string
-	Title     string
-	Duration  string
-	VideoURL  string
-}
-
 type SongSearch struct {
-	Id   string
-	Name string
+	Id       string
+	Name     string
+	Duration string // Clock-style duration ("4:05"), empty when unknown
+	Channel  string
+	Live     bool // Live stream or upcoming premiere
 }
 
 // Command struct for commands
@@ -43,14 +35,16 @@ type VoiceInstance struct {
 	session       *discordgo.Session
 	guildID       string
 	voice         *discordgo.VoiceConnection
-	encoder       *dca.EncodeSession
-	stream        *dca.StreamingSession
-	nowPlaying    Song
+	nowPlaying    Track
 	stop          bool
 	speaking      bool
 	paused        bool
 	currentUserID string // Track the user who initiated the current session
 	playStartTime time.Time // Track when current song started playing
+	pausedAt      time.Time     // When the current pause began, zero while playing
+	pausedTotal   time.Duration // Time the current song spent paused before pausedAt
+	looping       bool          // Repeat the current song until looping is turned off
+	interrupting  bool          // The current song is being cut off by playnow and was re-queued to resume
 }
 
 type BadQualitySongNodes struct {
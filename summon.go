@@ -0,0 +1,110 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// requireSameVoiceChannel refuses playback commands from people in a different voice channel
+// than the one the bot is playing in. People who aren't in voice can still use text commands.
+func requireSameVoiceChannel(m *discordgo.MessageCreate) bool {
+	if v.nowPlaying == (Track{}) || v.voice == nil {
+		return true
+	}
+	channelID := SearchVoiceChannel(m.GuildID, m.Author.ID)
+	if channelID == "" || channelID == v.voice.ChannelID {
+		return true
+	}
+
+	s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("**[Muse]** I'm playing in <#%s> right now. Join that channel, or use `summon` to bring me over to yours.", v.voice.ChannelID))
+	return false
+}
+
+// joinCommand brings the bot to the caller's voice channel: `join`, `summon` or `move here`.
+// A playing song pauses while the connection moves and carries on in the new channel.
+func joinCommand(s *discordgo.Session, m *discordgo.MessageCreate) {
+	channelID := SearchVoiceChannel(m.GuildID, m.Author.ID)
+	if channelID == "" {
+		s.ChannelMessageSend(m.ChannelID, "**[Muse]** Join a voice channel first, then I'll come over.")
+		return
+	}
+	if v.voice != nil && v.voice.Ready && v.voice.ChannelID == channelID {
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("**[Muse]** I'm already in <#%s>.", channelID))
+		return
+	}
+
+	// Nothing is sent while the connection moves, so the song doesn't skip ahead
+	holdPlayback := v.nowPlaying != (Track{}) && !v.paused
+	if holdPlayback {
+		v.setPaused(true)
+	}
+
+	vc, err := s.ChannelVoiceJoin(m.GuildID, channelID, false, true)
+	if err == nil {
+		for i := 0; i < VoiceReadyRetries && !vc.Ready; i++ {
+			time.Sleep(VoiceReadyWaitTime)
+		}
+		if !vc.Ready {
+			err = fmt.Errorf("voice connection to channel %s never became ready", channelID)
+		}
+	}
+	if err != nil {
+		if holdPlayback {
+			v.setPaused(false)
+		}
+		voiceErr := NewVoiceError("Failed to join voice channel",
+			"Could not join your voice channel. Please check permissions.", err).
+			WithContext("guild_id", m.GuildID).
+			WithContext("user_id", m.Author.ID)
+		errorHandler.Handle(voiceErr, m.ChannelID)
+		return
+	}
+
+	v.voice = vc
+	v.currentUserID = m.Author.ID
+	log.Printf("INFO: Moved to voice channel %s for user %s", channelID, m.Author.ID)
+
+	if holdPlayback {
+		v.setPaused(false)
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("**[Muse]** :wave: Moved to <#%s>, carrying on with [%s]", channelID, v.nowPlaying.Title))
+		go refreshNowPlayingCard()
+		return
+	}
+	s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("**[Muse]** :wave: Joined <#%s>", channelID))
+
+	if v.nowPlaying != (Track{}) {
+		return // Paused, `resume` carries on here
+	}
+	queueMutex.Lock()
+	queued := len(queue)
+	queueMutex.Unlock()
+	if queued > 0 {
+		startPlaybackIfIdle(m)
+	} else {
+		startIdleTimer(m.ChannelID) // Don't sit here forever if nothing gets queued
+	}
+}
+
+// leaveCommand stops playback and leaves voice; the queue can be brought back with `undo`
+func leaveCommand(s *discordgo.Session, m *discordgo.MessageCreate) {
+	if v.voice == nil {
+		s.ChannelMessageSend(m.ChannelID, "**[Muse]** I'm not in a voice channel.")
+		return
+	}
+	if !requireSameVoiceChannel(m) {
+		return
+	}
+
+	channelID := v.voice.ChannelID
+	saved := saveQueueAndLeave("cleared %d songs when leaving")
+	log.Printf("INFO: Left voice channel %s on request of user %s", channelID, m.Author.ID)
+
+	message := fmt.Sprintf("**[Muse]** :wave: Left <#%s>.", channelID)
+	if saved > 0 {
+		message += fmt.Sprintf(" Type `undo` to get the %d songs back, then `play` something to start again.", saved)
+	}
+	s.ChannelMessageSend(m.ChannelID, message)
+}
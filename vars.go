@@ -5,19 +5,19 @@ import (
 	"sync"
 	"time"
 
+	"automuse/internal/services/audio"
+	"automuse/internal/services/ytapi"
+
 	"github.com/bwmarrin/discordgo"
-	"github.com/jonas747/dca"
 	yt "github.com/kkdai/youtube/v2"
-	"google.golang.org/api/youtube/v3"
 )
 
 // Bot Parameters
 var (
-	searchRequested bool
-	stopRequested   bool         // Flag to prevent queue processing after stop command
-	stopMutex       sync.RWMutex // Mutex for thread-safe stopRequested access
-	playbackEnding  bool         // Flag to indicate playback is ending naturally
-	playbackMutex   sync.RWMutex // Mutex for thread-safe playbackEnding access
+	stopRequested  bool         // Flag to prevent queue processing after stop command
+	stopMutex      sync.RWMutex // Mutex for thread-safe stopRequested access
+	playbackEnding bool         // Flag to indicate playback is ending naturally
+	playbackMutex  sync.RWMutex // Mutex for thread-safe playbackEnding access
 
 	// Rate limiting and resource management
 	maxConcurrentPlaylists = 3                          // Maximum number of playlists that can be processed simultaneously
@@ -25,6 +25,14 @@ var (
 	userRateLimit          = make(map[string]time.Time) // Per-user rate limiting
 	userRateMutex          sync.RWMutex                 // Mutex for user rate limiting
 	maxQueueSize           = 500                        // Maximum total queue size to prevent memory issues
+	maxPlaylistSize        = 100                        // Maximum songs taken from one playlist (after range/filter options)
+	defaultChannelUploads  = 10                         // Uploads queued by `play channel` when no count is given
+	searchFallbackEnabled  = true                       // Use yt-dlp search when the YouTube API is unavailable
+	idleTimeout            = 5 * time.Minute            // Stay in voice this long after the queue ends, 0 to leave right away
+	emptyChannelTimeout    = 5 * time.Minute            // Leave this long after everyone left the voice channel, 0 to stay paused
+	autoReconnectVoice     = true                       // Rejoin and resume when the voice connection drops
+	voiceReconnectAttempts = 5                          // Rejoin attempts before giving up on a dropped voice connection
+	voiceReconnectDelay    = 2 * time.Second            // Wait between rejoin attempts
 
 	// Playback state protection - prevent multiple simultaneous playback
 	isPlaying          bool
@@ -34,15 +42,14 @@ var (
 	activeCommands map[string]time.Time // Track active commands by user+command
 	commandMutex   sync.RWMutex         // Mutex for command tracking
 
-	service         *youtube.Service
+	youtubeAPI      *ytapi.Client  // YouTube Data API client, nil when no API key is configured
+	audioManager    *audio.Manager // Plays every song, see playTrack
 	s               *discordgo.Session
 	v               = new(VoiceInstance)
-	opts            = dca.StdEncodeOptions
 	client          = yt.Client{}   // Enable debug mode
 	ctx             context.Context // Assigned from main application context
-	song            = Song{}
-	searchQueue     = []SongSearch{}
-	queue           = []Song{}
+	song            = Track{}
+	queue           = []Track{}
 	queueMutex      sync.Mutex       // Mutex for thread-safe queue operations
 	metadataManager *MetadataManager // Metadata manager for song caching
 	bufferManager   *BufferManager   // Pre-download buffer manager (initialized conditionally)
@@ -53,6 +60,10 @@ var (
 		&PlayHelpCommand{},
 		&PlayStuffCommand{},
 		&PlayKudasaiCommand{},
+		&NowPlayingCommand{}, // These come before PlayCommand, which takes anything starting with "play"
+		&PlayNextCommand{},
+		&PlayNowCommand{},
+		&ReplayCommand{},
 		&PlayCommand{},
 		&StopCommand{},
 		&SkipCommand{},
@@ -60,13 +71,23 @@ var (
 		&ResumeCommand{},
 		&QueueCommand{},
 		&RemoveCommand{},
+		&ClearQueueCommand{},
+		&UndoQueueCommand{},
 		&CacheCommand{},
 		&CacheClearCommand{},
 		&BufferStatusCommand{},
+		&JoinCommand{}, // Before MoveQueueCommand, which takes anything starting with "move "
+		&LeaveCommand{},
 		&MoveQueueCommand{},
 		&ShuffleQueueCommand{},
+		&UnshuffleQueueCommand{},
 		&EmergencyResetCommand{},
 		&HistoryCommand{},
+		&FindCommand{},
+		&BackCommand{},
+		&StatusCommand{},
+		&LoopCommand{},
+		&SettingsCommand{},
 	}
 )
 
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"time"
+)
+
+// reconnectVoice gets the voice connection back, first giving discordgo's own reconnect a chance
+// and then rejoining the same channel, up to voiceReconnectAttempts times
+func reconnectVoice() bool {
+	if !autoReconnectVoice || v.voice == nil {
+		return false
+	}
+	channelID := v.voice.ChannelID
+
+	for attempt := 1; attempt <= voiceReconnectAttempts; attempt++ {
+		time.Sleep(voiceReconnectDelay)
+		if v.voice == nil || isStopRequested() {
+			return false // Left or stopped in the meantime
+		}
+		if v.voice.Ready {
+			log.Printf("INFO: Voice connection to channel %s came back by itself", channelID)
+			return true
+		}
+
+		vc, err := s.ChannelVoiceJoin(v.guildID, channelID, false, true)
+		if err == nil && vc.Ready {
+			v.voice = vc
+			log.Printf("INFO: Rejoined voice channel %s after %d attempts", channelID, attempt)
+			return true
+		}
+		log.Printf("WARN: Voice reconnect attempt %d/%d failed: %v", attempt, voiceReconnectAttempts, err)
+	}
+	return false
+}
+
+// recoverVoice reconnects after the voice connection dropped mid-song, keeping the channel posted
+func recoverVoice(textChannelID string, resumeAt time.Duration) bool {
+	if !autoReconnectVoice || v.voice == nil {
+		return false
+	}
+
+	s.ChannelMessageSend(textChannelID, "**[Muse]** 📡 Lost the voice connection, reconnecting...")
+	if !reconnectVoice() {
+		return false
+	}
+
+	s.ChannelMessageSend(textChannelID, fmt.Sprintf("**[Muse]** 📡 Reconnected! Resuming [%s] from %s", v.nowPlaying.Title, formatClock(resumeAt)))
+	return true
+}
+
+// checkVoiceAfterResume rejoins voice after a gateway resume when the bot was sitting idle in a
+// channel. While a song plays, a dead connection is noticed by playback itself.
+func checkVoiceAfterResume() {
+	if v.voice == nil || v.nowPlaying != (Track{}) {
+		return
+	}
+
+	time.Sleep(voiceReconnectDelay) // discordgo reconnects voice on its own after a resume
+	if v.voice == nil || v.voice.Ready || v.nowPlaying != (Track{}) {
+		return
+	}
+
+	log.Printf("INFO: Voice connection didn't survive the gateway resume, rejoining")
+	if !reconnectVoice() {
+		log.Printf("WARN: Couldn't rejoin voice after the gateway resume, leaving")
+		disconnectVoice()
+	}
+}
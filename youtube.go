@@ -3,12 +3,14 @@ package main
 import (
 	"flag"
 	"fmt"
+	"html"
 	"log"
-	"strconv"
 	"strings"
+	"time"
+
+	"automuse/internal/services/ytapi"
 
 	"github.com/bwmarrin/discordgo"
-	"google.golang.org/api/youtube/v3"
 )
 
 var (
@@ -25,92 +27,227 @@ type YouTubeFormat struct {
 	Cipher        string
 }
 
-// Retrieve playlistItems in the specified playlist
-func searchQueryList(req string) map[string]string {
-	// Make the API call to YouTube.
-	var part = []string{"id", "snippet"}
+// searchPage is one page of search results after filtering
+type searchPage struct {
+	Results       []SongSearch // Results in the order they should be shown
+	NextPageToken string       // API token for the next page, "" when results came from yt-dlp
+	HasMore       bool         // Whether asking for the next page can return more results
+}
 
-	call := service.Search.List(part).
-		Q(req).
-		MaxResults(*maxResults)
-	response, err := call.Do()
-	if err != nil {
-		log.Println(err)
+// searchQueryList returns one page of video results in relevance order, using the Data API when it
+// is available and falling back to a yt-dlp search when the API errors, is out of quota or has no key.
+// page is 0-based; pageToken is the API token returned for the previous page, if any.
+// The options filter the page and move cached songs to the front.
+func searchQueryList(req string, opts SearchOptions, page int, pageToken string) searchPage {
+	var videos []SongSearch
+	var result searchPage
+	var err error
+	searched := false // Whether the API answered; no results is an answer too
+
+	// Later pages can only come from the API if the previous page did
+	if youtubeAPI != nil && (page == 0 || pageToken != "") {
+		videos, result.NextPageToken, err = searchWithAPI(req, opts, pageToken)
+		searched = err == nil
+		if err != nil {
+			if ytapi.IsQuotaError(err) {
+				log.Printf("WARN: YouTube API quota exhausted, searching with yt-dlp until %s", ytapi.NextQuotaReset(time.Now()).Format(time.RFC1123))
+			} else {
+				log.Printf("WARN: YouTube API search failed: %v", err)
+			}
+			videos = nil
+		}
+		result.HasMore = result.NextPageToken != ""
 	}
 
-	// Group video, channel, and playlist results in separate lists.
-	videos := make(map[string]string)
+	if !searched && searchFallbackEnabled {
+		log.Printf("INFO: Searching with yt-dlp: %s (page %d)", req, page+1)
+		videos, err = searchWithYtDlp(req, page)
+		if err != nil {
+			log.Printf("ERROR: yt-dlp search failed: %v", err)
+		}
+		result.NextPageToken = ""
+		result.HasMore = len(videos) >= int(*maxResults)
+	}
+
+	printIDs("Videos", videos)
 
-	// Iterate through each item and add it to the correct list.
+	result.Results = opts.Apply(videos)
+	return result
+}
+
+// searchUntilResults fetches pages starting at page until one has results left after filtering,
+// so strict filters don't show an empty page. It returns the page and its 0-based index.
+func searchUntilResults(req string, opts SearchOptions, page int, pageToken string) (searchPage, int) {
+	for {
+		result := searchQueryList(req, opts, page, pageToken)
+		if len(result.Results) > 0 || !result.HasMore || page+1 >= maxSearchPages {
+			return result, page
+		}
+		log.Printf("INFO: No results on page %d passed the filters (%s), trying the next page", page+1, opts.Describe())
+		page++
+		pageToken = result.NextPageToken
+	}
+}
+
+// searchWithAPI runs a search through the YouTube Data API, then looks up durations for the results
+func searchWithAPI(req string, opts SearchOptions, pageToken string) ([]SongSearch, string, error) {
+	// Let the API do the duration filtering so the page isn't mostly filtered away
+	videoDuration := ""
+	if opts.Long {
+		videoDuration = "long"
+	} else if opts.Short {
+		videoDuration = "short"
+	}
+
+	// Make the API call to YouTube.
+	response, err := youtubeAPI.Search(ctx, req, videoDuration, pageToken, *maxResults)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// Keep the API's relevance order
+	var videos []SongSearch
 	for _, item := range response.Items {
-		switch item.Id.Kind {
-		case "youtube#video":
-			videos[item.Id.VideoId] = item.Snippet.Title
+		if item.Id.Kind == "youtube#video" {
+			videos = append(videos, SongSearch{
+				Id:      item.Id.VideoId,
+				Name:    html.UnescapeString(item.Snippet.Title),
+				Channel: html.UnescapeString(item.Snippet.ChannelTitle),
+				Live:    item.Snippet.LiveBroadcastContent == "live" || item.Snippet.LiveBroadcastContent == "upcoming",
+			})
 		}
 	}
 
-	printIDs("Videos", videos)
+	// Search results don't include durations, a single videos.list call does
+	if err := fillAPIDurations(videos); err != nil {
+		log.Printf("WARN: Could not look up durations for search results: %v", err)
+	}
 
-	return videos
+	return videos, response.NextPageToken, nil
 }
 
-// Retrieve playlistItems in the specified playlist
-func playlistItemsList(service *youtube.Service, part []string, playlistId string, pageToken string) *youtube.PlaylistItemListResponse {
-	call := service.PlaylistItems.List(part)
-	call = call.PlaylistId(playlistId)
-	if pageToken != "" {
-		call = call.PageToken(pageToken)
+// fillAPIDurations sets the Duration of each result from the videos' content details
+func fillAPIDurations(videos []SongSearch) error {
+	if len(videos) == 0 {
+		return nil
 	}
-	response, err := call.Do()
-	log.Println(err)
 
+	ids := make([]string, 0, len(videos))
+	for _, video := range videos {
+		ids = append(ids, video.Id)
+	}
+
+	response, err := youtubeAPI.Videos(ctx, []string{"contentDetails"}, ids)
+	if err != nil {
+		return err
+	}
+
+	durations := make(map[string]string, len(response.Items))
+	for _, item := range response.Items {
+		// Live streams report a zero duration, leave those blank
+		if d, err := parseISODuration(item.ContentDetails.Duration); err == nil && d > 0 {
+			durations[item.Id] = formatClock(d)
+		}
+	}
+	for i := range videos {
+		videos[i].Duration = durations[videos[i].Id]
+	}
+
+	return nil
+}
+
+// searchWithYtDlp runs a search through yt-dlp's ytsearchN: pseudo-URL, which needs no API key.
+// yt-dlp has no page tokens, so later pages are fetched by asking for more results and skipping the earlier ones.
+func searchWithYtDlp(req string, page int) ([]SongSearch, error) {
+	pageSize := int(*maxResults)
+	entries, err := fetchPlaylistEntries(fmt.Sprintf("ytsearch%d:%s", pageSize*(page+1), req), 0)
 	if err != nil {
-		return &youtube.PlaylistItemListResponse{}
-	} else {
-		return response
+		return nil, err
+	}
+
+	var videos []SongSearch
+	for i := pageSize * page; i < len(entries); i++ {
+		videos = append(videos, SongSearch{
+			Id:       entries[i].ID,
+			Name:     entries[i].Title,
+			Duration: entries[i].Duration,
+			Channel:  entries[i].Channel,
+			Live:     entries[i].Live,
+		})
 	}
+
+	return videos, nil
 }
 
-// Fetches and displays the queue
-func getSearch(m *discordgo.MessageCreate) {
-	searchQueue = []SongSearch{}
-	searchQuery := strings.SplitN(m.Content, "play ", 2)[1]
-	results := searchQueryList(searchQuery)
+// Searches for the query in the play command and shows the first page of results to the user who
+// searched, or queues the best result straight away for `play!` / `play --first`
+func getSearch(commData []string, m *discordgo.MessageCreate) {
+	opts, searchQuery, err := parseSearchArgs(commData[1:])
+	if err != nil {
+		s.ChannelMessageSend(m.ChannelID, "**[Muse]** "+err.Error())
+		return
+	}
+	if searchQuery == "" {
+		s.ChannelMessageSend(m.ChannelID, "**[Muse]** What should I search for? e.g. `play! never gonna give you up`")
+		return
+	}
+
 	s.ChannelMessageSend(m.ChannelID, "**[Muse]** Fetching Search Results...")
-	searchList := ":musical_note:   TOP RESULTS   :musical_note:\n"
-	index := 1
-
-	for id, name := range results {
-		// Check if this song is cached
-		cachedIndicator := ""
-		if metadataManager.HasSong(id) {
-			cachedIndicator = " :recycle:"
+	result, page := searchUntilResults(searchQuery, opts, 0, "")
+	if len(result.Results) == 0 {
+		if opts.HasFilters() {
+			s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("**[Muse]** No search results matched your filters (%s), try loosening them.", opts.Describe()))
+		} else {
+			s.ChannelMessageSend(m.ChannelID, "**[Muse]** No search results found, try different search terms.")
 		}
+		resetSearch(m.GuildID, m.Author.ID)
+		return
+	}
 
-		searchList = searchList + " " + strconv.Itoa(index) + ". " + name + cachedIndicator + "\n"
-		index = index + 1
-		searchQueue = append(searchQueue, SongSearch{id, name})
+	// Feeling lucky: queue the top result without a picker
+	if opts.First {
+		resetSearch(m.GuildID, m.Author.ID)
+		log.Printf("INFO: Queueing top search result for %q: %s", searchQuery, result.Results[0].Name)
+		queueSearchResult(result.Results[0], m)
+		return
 	}
 
-	searchRequested = true
-	s.ChannelMessageSend(m.ChannelID, searchList)
-	log.Println(searchList)
-}
+	session := &searchSession{
+		UserID:        m.Author.ID,
+		UserName:      m.Author.Username,
+		GuildID:       m.GuildID,
+		ChannelID:     m.ChannelID,
+		Query:         searchQuery,
+		Options:       opts,
+		Page:          page,
+		Results:       result.Results,
+		NextPageToken: result.NextPageToken,
+		HasMore:       result.HasMore,
+	}
+
+	embed, components := renderSearchResults(session)
+	msg, err := s.ChannelMessageSendComplex(m.ChannelID, &discordgo.MessageSend{
+		Embeds:     []*discordgo.MessageEmbed{embed},
+		Components: components,
+	})
+	if err != nil {
+		log.Printf("ERROR: Failed to send search results: %v", err)
+		return
+	}
 
-// Reset the search queue and search requested flag
-func resetSearch() {
-	searchQueue = []SongSearch{}
-	searchRequested = false
+	session.MessageID = msg.ID
+	storeSearchSession(session)
+	log.Printf("INFO: Showing %d search results for %q to user %s", len(result.Results), searchQuery, m.Author.ID)
 }
 
 // Print the ID and title of each result in a list as well as a name that
 // identifies the list. For example, print the word section name "Videos"
 // above a list of video search results, followed by the video ID and title
 // of each matching video.
-func printIDs(sectionName string, matches map[string]string) {
+func printIDs(sectionName string, matches []SongSearch) {
 	fmt.Printf("%v:\n", sectionName)
-	for id, title := range matches {
-		fmt.Printf("[%v] %v\n", id, title)
+	for _, match := range matches {
+		fmt.Printf("[%v] %v\n", match.Id, match.Name)
 	}
 	fmt.Printf("\n\n")
 }